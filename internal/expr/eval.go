@@ -0,0 +1,362 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type function func(args []any) (any, error)
+
+var functions map[string]function = map[string]function{
+	"len": func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, errors.New("len() expects 1 argument")
+		}
+		switch v := args[0].(type) {
+		case nil:
+			return 0.0, nil
+		case string:
+			return float64(len([]rune(v))), nil
+		case []any:
+			return float64(len(v)), nil
+		case map[string]any:
+			return float64(len(v)), nil
+		}
+		return nil, errors.Errorf("len() unsupported for %s", typeName(args[0]))
+	},
+	"pad": func(args []any) (any, error) {
+		if len(args) != 2 {
+			return nil, errors.New("pad() expects 2 arguments")
+		}
+		width, ok := toNumber(args[1])
+		if !ok {
+			return nil, errors.Errorf("pad() width must be a number, got %s", typeName(args[1]))
+		}
+		text := Format(args[0])
+		if n, ok := toNumber(args[0]); ok && n < 0 {
+			return "-" + padLeft(Format(-n), int(width)-1), nil
+		}
+		return padLeft(text, int(width)), nil
+	},
+}
+
+// Left pads a text with zeros up to the width
+func padLeft(text string, width int) string {
+	if n := width - len([]rune(text)); n > 0 {
+		return strings.Repeat("0", n) + text
+	}
+	return text
+}
+
+type evaluator struct {
+	scope *Scope
+
+	// missing keys, out of range indices and undefined variables evaluate to null instead of failing
+	optional bool
+}
+
+func (ev *evaluator) eval(n node) (any, error) {
+	s := ev.scope
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *identNode:
+		v, ok := s.Lookup(n.name)
+		if !ok {
+			if ev.optional {
+				return nil, nil
+			}
+			return nil, errors.Errorf("`%s`: not defined", n.name)
+		}
+		return v, nil
+	case *memberNode:
+		target, err := ev.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := target.(map[string]any)
+		if !ok {
+			if target == nil && ev.optional {
+				return nil, nil
+			}
+			return nil, errors.Errorf("`%s`: cannot read field `%s` of %s", n, n.name, typeName(target))
+		}
+		v, ok := obj[n.name]
+		if !ok {
+			if ev.optional {
+				return nil, nil
+			}
+			return nil, errors.Errorf("`%s`: key `%s` not found", n, n.name)
+		}
+		return v, nil
+	case *indexNode:
+		target, err := ev.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		index, err := ev.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		switch t := target.(type) {
+		case []any:
+			f, ok := toNumber(index)
+			if !ok || f != math.Trunc(f) {
+				return nil, errors.Errorf("`%s`: invalid array index %s", n, Format(index))
+			}
+			i := int(f)
+			if i < 0 || i >= len(t) {
+				if ev.optional {
+					return nil, nil
+				}
+				return nil, errors.Errorf("`%s`: index %d out of range (length %d)", n, i, len(t))
+			}
+			return t[i], nil
+		case map[string]any:
+			key := Format(index)
+			v, ok := t[key]
+			if !ok {
+				if ev.optional {
+					return nil, nil
+				}
+				return nil, errors.Errorf("`%s`: key `%s` not found", n, key)
+			}
+			return v, nil
+		}
+		if target == nil && ev.optional {
+			return nil, nil
+		}
+		return nil, errors.Errorf("`%s`: cannot index %s", n, typeName(target))
+	case *unaryNode:
+		v, err := ev.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !Truthy(v), nil
+		}
+		f, ok := toNumber(v)
+		if !ok {
+			return nil, errors.Errorf("`%s`: cannot negate %s", n, typeName(v))
+		}
+		return -f, nil
+	case *binaryNode:
+		return ev.evalBinary(n)
+	case *callNode:
+		fn, ok := functions[n.name]
+		if !ok {
+			return nil, errors.Errorf("`%s`: unknown function", n.name)
+		}
+		args := make([]any, len(n.args))
+		for i, a := range n.args {
+			v, err := ev.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		v, err := fn(args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "`%s`", n)
+		}
+		return v, nil
+	}
+	return nil, errors.Errorf("unsupported expression `%s`", n)
+}
+
+func (ev *evaluator) evalBinary(n *binaryNode) (any, error) {
+	left, err := ev.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit logical operators
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := ev.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := ev.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	}
+
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equals(left, right), nil
+	case "!=":
+		return !equals(left, right), nil
+	}
+
+	if (left == nil || right == nil) && ev.optional {
+		// ordering against a missing value never holds
+		switch n.op {
+		case "<", "<=", ">", ">=":
+			return false, nil
+		}
+	}
+
+	lf, lok := toNumber(left)
+	rf, rok := toNumber(right)
+
+	if n.op == "+" && (!lok || !rok) {
+		// string concatenation
+		return Format(left) + Format(right), nil
+	}
+
+	if !lok || !rok {
+		ls, lstr := left.(string)
+		rs, rstr := right.(string)
+		if lstr && rstr {
+			switch n.op {
+			case "<":
+				return ls < rs, nil
+			case "<=":
+				return ls <= rs, nil
+			case ">":
+				return ls > rs, nil
+			case ">=":
+				return ls >= rs, nil
+			}
+		}
+		return nil, errors.Errorf("`%s`: operator `%s` unsupported for %s and %s", n, n.op, typeName(left), typeName(right))
+	}
+
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errors.Errorf("`%s`: division by zero", n)
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, errors.Errorf("`%s`: division by zero", n)
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, errors.Errorf("unsupported operator `%s`", n.op)
+}
+
+func equals(a, b any) bool {
+	af, aok := toNumber(a)
+	bf, bok := toNumber(b)
+	if aok && bok {
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// Returns the truthiness of a value. Null, false, zero, empty strings,
+// empty arrays and empty objects are false. Everything else is true.
+func Truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case []any:
+		return len(t) > 0
+	case map[string]any:
+		return len(t) > 0
+	}
+	if f, ok := toNumber(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+// Formats a value for display. Whole numbers are printed without decimals and
+// arrays or objects are printed as JSON.
+func Format(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case []any, map[string]any:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	if f, ok := toNumber(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
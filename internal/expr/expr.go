@@ -0,0 +1,132 @@
+// Package expr implements the small expression language used by templates to
+// bind data, e.g. `${customer.name}` or `${items[0].price * 2}`.
+package expr
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A compiled expression
+type Expression struct {
+	src  string
+	root node
+}
+
+func Compile(src string) (*Expression, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression `%s`", src)
+	}
+	return &Expression{src: src, root: root}, nil
+}
+
+func (e *Expression) Eval(s *Scope) (any, error) {
+	ev := &evaluator{scope: s}
+	return ev.eval(e.root)
+}
+
+// Evaluates the expression as a condition. Missing keys and undefined variables
+// evaluate to null, so `discount > 0` holds false when there is no discount.
+func (e *Expression) Test(s *Scope) (bool, error) {
+	ev := &evaluator{scope: s, optional: true}
+	v, err := ev.eval(e.root)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Compiles and evaluates an expression
+func Eval(src string, s *Scope) (any, error) {
+	e, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return e.Eval(s)
+}
+
+// Compiles and evaluates a condition
+func Test(src string, s *Scope) (bool, error) {
+	e, err := Compile(src)
+	if err != nil {
+		return false, err
+	}
+	return e.Test(s)
+}
+
+// Replaces every `${expression}` in text with its formatted value.
+// Use `$${` to output a literal `${`.
+func Interpolate(text string, s *Scope) (string, error) {
+	return interpolate(text, s, false)
+}
+
+// Like Interpolate, but expressions that fail to evaluate are output as written
+func InterpolateLenient(text string, s *Scope) string {
+	text, _ = interpolate(text, s, true)
+	return text
+}
+
+func interpolate(text string, s *Scope, lenient bool) (string, error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, "${")
+		if start < 0 {
+			sb.WriteString(text)
+			break
+		}
+		if start > 0 && text[start-1] == '$' {
+			sb.WriteString(text[:start-1])
+			sb.WriteString("${")
+			text = text[start+2:]
+			continue
+		}
+		end := closingBrace(text, start+2)
+		if end < 0 {
+			if !lenient {
+				return "", errors.Errorf("unterminated expression in `%s`", text)
+			}
+			sb.WriteString(text)
+			break
+		}
+		sb.WriteString(text[:start])
+		v, err := Eval(text[start+2:end], s)
+		switch {
+		case err != nil && lenient:
+			sb.WriteString(text[start : end+1])
+		case err != nil:
+			return "", err
+		default:
+			sb.WriteString(Format(v))
+		}
+		text = text[end+1:]
+	}
+	return sb.String(), nil
+}
+
+// Returns the index of the `}` closing the expression starting at from, skipping the braces
+// of string literals, or -1 when there is none
+func closingBrace(text string, from int) int {
+	for i := from; i < len(text); i++ {
+		switch text[i] {
+		case '}':
+			return i
+		case '\'', '"':
+			quote := text[i]
+			for i++; i < len(text) && text[i] != quote; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,89 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+type customer struct {
+	Name   string   `json:"name"`
+	Emails []string `json:"emails"`
+}
+
+func TestInterpolate(t *testing.T) {
+	scope, err := expr.NewScope(map[string]any{
+		"customer": customer{Name: "ACME", Emails: []string{"a@acme.com", "b@acme.com"}},
+		"items":    []map[string]any{{"qty": 2, "price": 1.5}},
+	})
+	assert.Nil(t, err)
+
+	text, err := expr.Interpolate("${customer.name} <${customer.emails[1]}>", scope)
+	assert.Nil(t, err)
+	assert.Equal(t, "ACME <b@acme.com>", text)
+
+	text, err = expr.Interpolate("Total: ${items[0].qty * items.0.price}", scope)
+	assert.Nil(t, err)
+	assert.Equal(t, "Total: 3", text)
+
+	text, err = expr.Interpolate(`${"{" + customer.name + "}"} ${'}'}`, scope)
+	assert.Nil(t, err)
+	assert.Equal(t, "{ACME} }", text)
+
+	_, err = expr.Interpolate(`${customer.name + "}`, scope)
+	assert.EqualError(t, err, "unterminated expression in `${customer.name + \"}`")
+
+	text, err = expr.Interpolate("$${literal}", scope)
+	assert.Nil(t, err)
+	assert.Equal(t, "${literal}", text)
+
+	_, err = expr.Interpolate("${customer.nmae}", scope)
+	assert.EqualError(t, err, "`customer.nmae`: key `nmae` not found")
+
+	_, err = expr.Interpolate("${customer.emails[5]}", scope)
+	assert.EqualError(t, err, "`customer.emails[5]`: index 5 out of range (length 2)")
+
+	text = expr.InterpolateLenient("${customer.name} ${customer.nmae} ${items[0].qty + } ${total", scope)
+	assert.Equal(t, "ACME ${customer.nmae} ${items[0].qty + } ${total", text)
+}
+
+func TestRawJSON(t *testing.T) {
+	scope, err := expr.NewScope([]byte(`{"overdue": true, "count": 0}`))
+	assert.Nil(t, err)
+
+	v, err := expr.Eval("overdue && !count", scope)
+	assert.Nil(t, err)
+	assert.Equal(t, true, v)
+
+	_, err = expr.NewScope([]byte(`[1, 2]`))
+	assert.NotNil(t, err)
+}
+
+func TestConditions(t *testing.T) {
+	scope, err := expr.NewScope(map[string]any{"invoice": map[string]any{"total": 10}})
+	assert.Nil(t, err)
+
+	ok, err := expr.Test("invoice.discount > 0", scope)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = expr.Test("!overdue && invoice.total >= 10", scope)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, err = expr.Eval("invoice.discount > 0", scope)
+	assert.NotNil(t, err)
+}
+
+func TestPad(t *testing.T) {
+	scope, err := expr.NewScope(map[string]any{"page": 7, "name": "ab"})
+	assert.Nil(t, err)
+
+	text, err := expr.Interpolate("${pad(page, 4)} ${pad(page * 2000, 3)} ${pad(-page, 3)} ${pad(name, 3)}", scope)
+	assert.Nil(t, err)
+	assert.Equal(t, "0007 14000 -07 0ab", text)
+
+	_, err = expr.Interpolate("${pad(page)}", scope)
+	assert.EqualError(t, err, "`pad(page)`: pad() expects 2 arguments")
+}
@@ -0,0 +1,98 @@
+package expr
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tkEOF tokenKind = iota
+	tkIdent
+	tkNumber
+	tkString
+	tkOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// Two character operators must come before their single character prefixes
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	".", "[", "]", "(", ")", ",", "!", "<", ">", "+", "-", "*", "/", "%",
+}
+
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(src)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tkIdent, value: string(runes[start:i]), pos: start})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			// a fraction needs a digit after the dot, otherwise `items.0.name` would not lex
+			if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) && !afterDot(tokens) {
+				i++
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tkNumber, value: string(runes[start:i]), pos: start})
+		case r == '\'' || r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tkString, value: sb.String(), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tkOperator, value: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errors.Errorf("unexpected character `%c` at position %d", r, i)
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tkEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// Reports whether the last token is a member access dot
+func afterDot(tokens []token) bool {
+	n := len(tokens)
+	return n > 0 && tokens[n-1].kind == tkOperator && tokens[n-1].value == "."
+}
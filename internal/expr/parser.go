@@ -0,0 +1,267 @@
+package expr
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type node interface {
+	String() string
+}
+
+type literalNode struct {
+	value any
+	text  string
+}
+
+type identNode struct {
+	name string
+}
+
+type memberNode struct {
+	target node
+	name   string
+}
+
+type indexNode struct {
+	target node
+	index  node
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n *literalNode) String() string { return n.text }
+func (n *identNode) String() string   { return n.name }
+func (n *memberNode) String() string  { return n.target.String() + "." + n.name }
+func (n *indexNode) String() string   { return n.target.String() + "[" + n.index.String() + "]" }
+func (n *unaryNode) String() string   { return n.op + n.operand.String() }
+func (n *binaryNode) String() string {
+	return n.left.String() + " " + n.op + " " + n.right.String()
+}
+func (n *callNode) String() string {
+	s := n.name + "("
+	for i, a := range n.args {
+		if i > 0 {
+			s += ", "
+		}
+		s += a.String()
+	}
+	return s + ")"
+}
+
+// binary operators grouped by precedence, lowest first
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tkEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	t := p.peek()
+	if t.kind == tkOperator && t.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return errors.Errorf("expected `%s` at position %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		matched := false
+		if t.kind == tkOperator {
+			for _, op := range precedence[level] {
+				if t.value == op {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.value, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "!", operand: operand}, nil
+	}
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "-", operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept(".") {
+			t := p.next()
+			if t.kind == tkIdent {
+				n = &memberNode{target: n, name: t.value}
+			} else if t.kind == tkNumber {
+				// allow `items.0` as a shorthand for `items[0]`
+				n = &indexNode{target: n, index: &literalNode{value: mustNumber(t.value), text: t.value}}
+			} else {
+				return nil, errors.Errorf("expected field name at position %d", t.pos)
+			}
+		} else if p.accept("[") {
+			index, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{target: n, index: index}
+		} else {
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tkNumber:
+		v, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid number `%s` at position %d", t.value, t.pos)
+		}
+		return &literalNode{value: v, text: t.value}, nil
+	case tkString:
+		return &literalNode{value: t.value, text: strconv.Quote(t.value)}, nil
+	case tkIdent:
+		switch t.value {
+		case "true":
+			return &literalNode{value: true, text: t.value}, nil
+		case "false":
+			return &literalNode{value: false, text: t.value}, nil
+		case "null", "nil":
+			return &literalNode{value: nil, text: t.value}, nil
+		}
+		if p.accept("(") {
+			call := &callNode{name: t.value}
+			if !p.accept(")") {
+				for {
+					arg, err := p.parseBinary(0)
+					if err != nil {
+						return nil, err
+					}
+					call.args = append(call.args, arg)
+					if p.accept(")") {
+						break
+					}
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return call, nil
+		}
+		return &identNode{name: t.value}, nil
+	case tkOperator:
+		if t.value == "(" {
+			n, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	case tkEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, errors.Errorf("unexpected `%s` at position %d", t.value, t.pos)
+}
+
+func mustNumber(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tkEOF {
+		return nil, errors.Errorf("unexpected `%s` at position %d", t.value, t.pos)
+	}
+	return n, nil
+}
@@ -0,0 +1,72 @@
+package expr
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DEFAULT_REPEAT_ITEM  = "item"
+	DEFAULT_REPEAT_INDEX = "index"
+)
+
+var (
+	identRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	repeatRegex = regexp.MustCompile(`^\s*([^\s,]+)\s*(?:,\s*([^\s,]+)\s*)?\s+in\s+(.+)$`)
+)
+
+// A repeat clause of the form `items`, `item in items` or `item, index in items`.
+// When omitted, the item and index variables are named `item` and `index`.
+type Repeat struct {
+	Item   string
+	Index  string
+	Source *Expression
+}
+
+func CompileRepeat(src string) (*Repeat, error) {
+	r := &Repeat{Item: DEFAULT_REPEAT_ITEM, Index: DEFAULT_REPEAT_INDEX}
+	source := src
+	if m := repeatRegex.FindStringSubmatch(src); m != nil {
+		r.Item = m[1]
+		if m[2] != "" {
+			r.Index = m[2]
+		}
+		source = m[3]
+		for _, name := range []string{r.Item, r.Index} {
+			if !identRegex.MatchString(name) {
+				return nil, errors.Errorf("invalid repeat variable `%s`", name)
+			}
+		}
+	}
+	e, err := Compile(strings.TrimSpace(source))
+	if err != nil {
+		return nil, err
+	}
+	r.Source = e
+	return r, nil
+}
+
+// Evaluates the source array. Null evaluates to an empty list.
+func (r *Repeat) Items(s *Scope) ([]any, error) {
+	v, err := r.Source.Eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch items := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return items, nil
+	}
+	return nil, errors.Errorf("`%s`: cannot repeat over %s", r.Source, typeName(v))
+}
+
+// Returns the scope for the item at index i
+func (r *Repeat) Scope(s *Scope, i int, item any) *Scope {
+	return s.With(map[string]any{
+		r.Item:  item,
+		r.Index: i,
+	})
+}
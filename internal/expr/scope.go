@@ -0,0 +1,70 @@
+package expr
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Variables visible to an expression. Scopes are chained so that inner scopes
+// (e.g. the current page, a repeated item) shadow the outer data.
+type Scope struct {
+	parent *Scope
+	vars   map[string]any
+}
+
+// Creates a root scope. The keys of the data object become top level variables.
+func NewScope(data any) (*Scope, error) {
+	s := &Scope{vars: map[string]any{}}
+	if data == nil {
+		return s, nil
+	}
+	normalized, err := Normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := normalized.(map[string]any)
+	if !ok {
+		return nil, errors.Errorf("data must be an object, got %s", typeName(normalized))
+	}
+	s.vars = obj
+	return s, nil
+}
+
+// Returns a child scope with additional variables
+func (s *Scope) With(vars map[string]any) *Scope {
+	return &Scope{parent: s, vars: vars}
+}
+
+// Looks up a variable, starting with the innermost scope
+func (s *Scope) Lookup(name string) (any, bool) {
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := sc.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Converts a Go value (map, struct, slice) or raw JSON ([]byte, json.RawMessage)
+// into the generic representation used by expressions. Structs honour their
+// `json` tags.
+func Normalize(data any) (any, error) {
+	var raw []byte
+	switch d := data.(type) {
+	case json.RawMessage:
+		raw = d
+	case []byte:
+		raw = d
+	default:
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return nil, errors.Wrap(err, "encode data")
+		}
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.Wrap(err, "decode data")
+	}
+	return v, nil
+}
@@ -0,0 +1,191 @@
+// Package hyphen finds the hyphenation points of words with Liang's algorithm, using the TeX pattern
+// dictionaries embedded in the patterns directory.
+package hyphen
+
+import (
+	"bufio"
+	"embed"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Language of the dictionary used when none is set
+const DefaultLanguage = "en-us"
+
+//go:embed patterns/*.pat
+var dictionaries embed.FS
+
+var (
+	mu     sync.Mutex
+	loaded = map[string]*Hyphenator{}
+)
+
+// Hyphenates the words of a language
+type Hyphenator struct {
+	patterns   map[string][]byte // letters of the pattern to the values between them
+	exceptions map[string][]int  // words to their hyphenation points
+	maxLen     int               // letters of the longest pattern
+	leftMin    int               // letters kept before the first hyphen
+	rightMin   int               // letters kept after the last hyphen
+}
+
+// Returns the hyphenator of the language, e.g. `en-us`. Languages without a dictionary of their own use the
+// first dictionary of their base language, `en` using `en-us`.
+func Get(lang string) (*Hyphenator, error) {
+	lang = strings.ToLower(lang)
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if h, ok := loaded[lang]; ok {
+		return h, nil
+	}
+	name, ok := lookup(lang)
+	if !ok {
+		return nil, errors.Errorf("no hyphenation patterns for language `%s`", lang)
+	}
+	fd, err := dictionaries.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open hyphenation patterns `%s`", lang)
+	}
+	defer fd.Close()
+	h, err := Parse(fd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "hyphenation patterns `%s`", lang)
+	}
+	loaded[lang] = h
+	return h, nil
+}
+
+// Returns the languages with an embedded dictionary
+func Languages() []string {
+	entries, _ := dictionaries.ReadDir("patterns")
+	langs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".pat"))
+	}
+	return langs
+}
+
+// Returns the dictionary file of the language, or of its base language
+func lookup(lang string) (string, bool) {
+	base, _, _ := strings.Cut(lang, "-")
+	for _, l := range Languages() {
+		if l == lang {
+			return path.Join("patterns", l+".pat"), true
+		}
+	}
+	for _, l := range Languages() {
+		if l == base || strings.HasPrefix(l, base+"-") {
+			return path.Join("patterns", l+".pat"), true
+		}
+	}
+	return "", false
+}
+
+// Parses a dictionary in the TeX pattern format: whitespace separated patterns such as `.ach4` or `a5bal`,
+// and exceptions, words without digits such as `ta-ble` hyphenated as written. Lines starting with `%` are comments.
+// Hyphens leave at least two letters before and three letters after them.
+func Parse(r io.Reader) (*Hyphenator, error) {
+	h := &Hyphenator{patterns: map[string][]byte{}, exceptions: map[string][]int{}, leftMin: 2, rightMin: 3}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "%") {
+			continue
+		}
+		for _, token := range strings.Fields(strings.ToLower(line)) {
+			if !strings.ContainsAny(token, "0123456789") {
+				parts := strings.Split(token, "-")
+				points, pos := []int{}, 0
+				for _, part := range parts[:len(parts)-1] {
+					pos += len([]rune(part))
+					points = append(points, pos)
+				}
+				h.exceptions[strings.Join(parts, "")] = points
+				continue
+			}
+			letters, values := []rune{}, []byte{0}
+			for _, r := range token {
+				if r >= '0' && r <= '9' {
+					values[len(values)-1] = byte(r - '0')
+					continue
+				}
+				letters = append(letters, r)
+				values = append(values, 0)
+			}
+			if len(letters) == 0 {
+				return nil, errors.Errorf("invalid pattern `%s`", token)
+			}
+			h.patterns[string(letters)] = values
+			h.maxLen = max(h.maxLen, len(letters))
+		}
+	}
+	return h, scanner.Err()
+}
+
+// Returns the positions, in runes, where the word can be broken in ascending order. Letters are hyphenated
+// run by run, so that punctuation is kept with its word, and words can also be broken after their own hyphens.
+func (h *Hyphenator) Points(word string) []int {
+	runes := []rune(word)
+	points := []int{}
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && unicode.IsLetter(runes[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			for _, p := range h.hyphenate(runes[start:i]) {
+				points = append(points, start+p)
+			}
+			start = -1
+		}
+		if i < len(runes)-1 && runes[i] == '-' && i > 0 {
+			points = append(points, i+1)
+		}
+	}
+	sort.Ints(points)
+	return points
+}
+
+// Returns the hyphenation points of a run of letters
+func (h *Hyphenator) hyphenate(letters []rune) []int {
+	if len(letters) < h.leftMin+h.rightMin {
+		return nil
+	}
+	word := []rune(strings.ToLower(string(letters)))
+	if points, ok := h.exceptions[string(word)]; ok {
+		return points
+	}
+	dotted := append(append([]rune{'.'}, word...), '.')
+	values := make([]byte, len(dotted)+1)
+	for i := range dotted {
+		for j := i + 1; j <= len(dotted) && j-i <= h.maxLen; j++ {
+			pattern, ok := h.patterns[string(dotted[i:j])]
+			if !ok {
+				continue
+			}
+			for k, v := range pattern {
+				values[i+k] = max(values[i+k], v)
+			}
+		}
+	}
+	points := []int{}
+	for p := h.leftMin; p <= len(word)-h.rightMin; p++ {
+		// values are offset by the leading dot
+		if values[p+1]%2 == 1 {
+			points = append(points, p)
+		}
+	}
+	return points
+}
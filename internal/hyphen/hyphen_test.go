@@ -0,0 +1,28 @@
+package hyphen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/hyphen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoints(t *testing.T) {
+	h, err := hyphen.Get("en")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 6}, h.Points("hyphenation"))
+	assert.Equal(t, []int{2}, h.Points("Table,"))
+	assert.Equal(t, []int{}, h.Points("present"))
+	assert.Equal(t, []int{6}, h.Points("terms-and"))
+
+	_, err = hyphen.Get("xx")
+	assert.EqualError(t, err, "no hyphenation patterns for language `xx`")
+}
+
+func TestParse(t *testing.T) {
+	h, err := hyphen.Parse(strings.NewReader("% comment\n1ba ta-ble"))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, h.Points("cabana"))
+	assert.Equal(t, []int{2}, h.Points("table"))
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/gintec-rdl/pdf-go/internal/hyphen"
 	"github.com/gintec-rdl/pdf-go/internal/utils"
 	"github.com/gintec-rdl/pdf-go/pkg/types"
 	"github.com/pkg/errors"
@@ -37,7 +39,153 @@ var (
 		e.Brush.StrokeWidth = w
 		return e, nil
 	}
+	conditionFn = func(e types.IElement, parent types.IElement, val any) error {
+		if _, err := expr.Compile(val.(string)); err != nil {
+			return errors.Wrap(err, "invalid condition")
+		}
+		e.GetElement().Condition = val.(string)
+		return nil
+	}
+	bookmarkLevelFn = func(e types.IElement, parent types.IElement, val any) error {
+		level, err := strconv.Atoi(strings.TrimSpace(val.(string)))
+		if err != nil || level < 1 {
+			return errors.Errorf("invalid bookmark level `%s`, expected a number from 1", val)
+		}
+		e.GetElement().BookmarkLevel = level
+		return nil
+	}
+	bookmarkToFn = func(e types.IElement, parent types.IElement, val any) error {
+		name := strings.TrimSpace(val.(string))
+		if name == "" {
+			return errors.New("missing anchor name")
+		}
+		e.GetElement().BookmarkTo = name
+		return nil
+	}
+	watermarkConditionFn = func(e types.IElement, parent types.IElement, val any) error {
+		if _, err := expr.Compile(val.(string)); err != nil {
+			return errors.Wrap(err, "invalid condition")
+		}
+		e.(*types.Document).Watermark.Condition = val.(string)
+		return nil
+	}
+	sectionAreaFn = func(e types.IElement) *types.SectionArea {
+		switch section := e.(type) {
+		case *types.Header:
+			return &section.SectionArea
+		case *types.Footer:
+			return &section.SectionArea
+		}
+		return nil
+	}
+	sectionHeightFn = func(e types.IElement, parent types.IElement, val any) error {
+		area := sectionAreaFn(e)
+		area.Height = new(types.Dimension)
+		return area.Height.UnmarshalText([]byte(val.(string)))
+	}
+	sectionMarginsFn = func(e types.IElement) *types.Spacing {
+		return &sectionAreaFn(e).Margins
+	}
+	// `margin` is the distance between the band and the page edge, on top of headers and below footers
+	sectionMarginFn = func(e types.IElement, parent types.IElement, val any) error {
+		if _, ok := e.(*types.Footer); ok {
+			return spacingSideFn(sectionMarginsFn, bottomFn)(e, parent, val)
+		}
+		return spacingSideFn(sectionMarginsFn, topFn)(e, parent, val)
+	}
+	pageSetupFn = func(e types.IElement) (*types.PageOrientation, *types.PageSize) {
+		switch el := e.(type) {
+		case *types.Page:
+			return &el.Orientation, &el.PageSize
+		case *types.Section:
+			return &el.Orientation, &el.PageSize
+		}
+		return nil, nil
+	}
+	pageSizeFn = func(e types.IElement, parent types.IElement, val any) error {
+		_, pageSize := pageSetupFn(e)
+		size := types.PageSize(strings.TrimSpace(val.(string)))
+		if err := size.Validate(); err != nil {
+			return err
+		}
+		*pageSize = size
+		return nil
+	}
+	orientationFn = func(e types.IElement, parent types.IElement, val any) error {
+		orientation, _ := pageSetupFn(e)
+		return orientation.Parse(val.(string))
+	}
+	marginsFn = func(e types.IElement) *types.Spacing {
+		switch el := e.(type) {
+		case *types.Document:
+			return &el.Margins
+		case *types.Section:
+			return &el.Margins
+		case *types.Page:
+			return &el.Margins
+		case *types.Cell:
+			return &el.Margin
+		}
+		return nil
+	}
+	paddingsFn = func(e types.IElement) *types.Spacing {
+		switch el := e.(type) {
+		case *types.Page:
+			return &el.Padding
+		case *types.Header:
+			return &el.Padding
+		case *types.Footer:
+			return &el.Padding
+		case *types.Cell:
+			return &el.Padding
+		}
+		return nil
+	}
+	// sets all sides of the spacing returned by get
+	spacingFn = func(get func(e types.IElement) *types.Spacing) AttributeHandler {
+		return func(e types.IElement, parent types.IElement, val any) error {
+			return get(e).Parse(val.(string))
+		}
+	}
+	// sets one side of the spacing returned by get
+	spacingSideFn = func(get func(e types.IElement) *types.Spacing, side func(m *types.Spacing) **types.Dimension) AttributeHandler {
+		return func(e types.IElement, parent types.IElement, val any) error {
+			dst := side(get(e))
+			*dst = new(types.Dimension)
+			return (*dst).UnmarshalText([]byte(val.(string)))
+		}
+	}
+	leftFn   = func(m *types.Spacing) **types.Dimension { return &m.Left }
+	topFn    = func(m *types.Spacing) **types.Dimension { return &m.Top }
+	rightFn  = func(m *types.Spacing) **types.Dimension { return &m.Right }
+	bottomFn = func(m *types.Spacing) **types.Dimension { return &m.Bottom }
+	// `true`, `false` or the variant of the page's header or footer
+	sectionVariantFn = func(val string, variant *types.SectionVariant, hidden *bool) error {
+		if show, err := strconv.ParseBool(val); err == nil {
+			*hidden = !show
+			return nil
+		}
+		*hidden = false
+		return variant.Parse(val)
+	}
+	spanFn = func(val string) (int, error) {
+		span, err := strconv.Atoi(val)
+		if err != nil || span < 1 {
+			return 0, errors.Errorf("invalid span `%s`, expected a positive number", val)
+		}
+		return span, nil
+	}
 	attributeHandlers map[string]AttributeHandler = map[string]AttributeHandler{
+		"cell.if":        conditionFn,
+		"cell.visible":   conditionFn,
+		"page.if":        conditionFn,
+		"page.visible":   conditionFn,
+		"header.if":      conditionFn,
+		"header.visible": conditionFn,
+		"footer.if":      conditionFn,
+		"footer.visible": conditionFn,
+		"row.if":         conditionFn,
+		"row.visible":    conditionFn,
 		"background-color": func(e types.IElement, parent types.IElement, val any) error {
 			alpha, color, err := utils.ParseColor(val.(string))
 			if err != nil {
@@ -75,6 +223,25 @@ var (
 			doc := e.GetElement()
 			return doc.TextStyle.FontStyle.UnmarshalText([]byte(val.(string)))
 		},
+		"wrap": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			doc := e.GetElement()
+			doc.TextStyle.Wrap, err = strconv.ParseBool(val.(string))
+			return err
+		},
+		"hyphenate": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			doc := e.GetElement()
+			doc.TextStyle.Hyphenate, err = strconv.ParseBool(val.(string))
+			return err
+		},
+		"lang": func(e types.IElement, parent types.IElement, val any) error {
+			if _, err := hyphen.Get(val.(string)); err != nil {
+				return err
+			}
+			e.GetElement().TextStyle.Language = val.(string)
+			return nil
+		},
 		"font-size": func(e types.IElement, parent types.IElement, val any) error {
 			doc := e.GetElement()
 			err := doc.TextStyle.FontSize.UnmarshalText([]byte(val.(string)))
@@ -114,6 +281,103 @@ var (
 			}
 			return nil
 		},
+		"document.watermark.font-family": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			doc.Watermark.TextStyle.FontName = val.(string)
+			return nil
+		},
+		"document.watermark.font-style": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			return doc.Watermark.TextStyle.FontStyle.UnmarshalText([]byte(val.(string)))
+		},
+		"document.watermark.angle": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			doc := e.(*types.Document)
+			doc.Watermark.Angle, err = strconv.ParseFloat(val.(string), 64)
+			if err != nil {
+				return errors.Errorf("invalid angle `%s`", val)
+			}
+			return nil
+		},
+		"document.watermark.position": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			table := []rune("LCRTMB")
+			values := strings.ToUpper(val.(string))
+			for _, r := range values {
+				if !slices.Contains(table, r) {
+					return errors.Errorf("invalid watermark position flag `%c`. expected any of `LCRTMB`", r)
+				}
+			}
+			doc.Watermark.Position = values
+			return nil
+		},
+		"document.watermark.layer": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Document).Watermark.Layer.Parse(val.(string))
+		},
+		"document.watermark.fit": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			fit, err := strconv.ParseBool(val.(string))
+			doc.Watermark.Fit = &fit
+			return err
+		},
+		"document.watermark.if":      watermarkConditionFn,
+		"document.watermark.visible": watermarkConditionFn,
+		"document.watermark.image": func(e types.IElement, parent types.IElement, val any) error {
+			src := strings.TrimSpace(val.(string))
+			if src == "" {
+				return errors.New("missing image source")
+			}
+			e.(*types.Document).Watermark.Image = src
+			return nil
+		},
+		"document.watermark.image-alpha": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			alpha, err := strconv.ParseFloat(val.(string), 64)
+			if err != nil || alpha < 0 || alpha > 1 {
+				return errors.Errorf("invalid image alpha `%s`, expected a value between 0 and 1", val)
+			}
+			doc.Watermark.ImageStyle.Alpha = &alpha
+			return nil
+		},
+		"document.watermark.width": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			if doc.Watermark.Width == nil {
+				doc.Watermark.Width = new(types.Dimension)
+			}
+			return doc.Watermark.Width.UnmarshalText([]byte(val.(string)))
+		},
+		"document.watermark.height": func(e types.IElement, parent types.IElement, val any) error {
+			doc := e.(*types.Document)
+			if doc.Watermark.Height == nil {
+				doc.Watermark.Height = new(types.Dimension)
+			}
+			return doc.Watermark.Height.UnmarshalText([]byte(val.(string)))
+		},
+		"page.watermark": func(e types.IElement, parent types.IElement, val any) error {
+			page := e.(*types.Page)
+			show, err := strconv.ParseBool(val.(string))
+			page.NoWatermark = !show
+			return err
+		},
+		"page.anchor": func(e types.IElement, parent types.IElement, val any) error {
+			e.(*types.Page).Anchor = strings.TrimSpace(val.(string))
+			return nil
+		},
+		"page.bookmark-to":    bookmarkToFn,
+		"cell.bookmark-to":    bookmarkToFn,
+		"section.bookmark-to": bookmarkToFn,
+		"page.size":           pageSizeFn,
+		"page.orientation":    orientationFn,
+		"section.size":        pageSizeFn,
+		"section.orientation": orientationFn,
+		"page.header": func(e types.IElement, parent types.IElement, val any) error {
+			page := e.(*types.Page)
+			return sectionVariantFn(val.(string), &page.HeaderVariant, &page.NoHeader)
+		},
+		"page.footer": func(e types.IElement, parent types.IElement, val any) error {
+			page := e.(*types.Page)
+			return sectionVariantFn(val.(string), &page.FooterVariant, &page.NoFooter)
+		},
 		"border-left-width": func(e types.IElement, parent types.IElement, val any) error {
 			doc := e.GetElement()
 			b, err := borderWidthFn(doc.Border.Left, []byte(val.(string)))
@@ -164,16 +428,39 @@ var (
 		},
 		"cell.text-align": func(e types.IElement, parent types.IElement, val any) error {
 			cell := e.(*types.Cell)
-			table := []rune("LCRBATM")
+			table := []rune("LCRBATMJ")
 			values := strings.ToUpper(val.(string))
 			for _, r := range values {
 				if !slices.Contains(table, r) {
-					return errors.Errorf("invalid text alignment flag `%c`. expected any of `LCRBATM`", r)
+					return errors.Errorf("invalid text alignment flag `%c`. expected any of `LCRBATMJ`", r)
 				}
 			}
 			cell.TextStyle.Alignment = val.(string)
 			return nil
 		},
+		"cell.content-type": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).ContentType.Parse(val.(string))
+		},
+		"cell.href": func(e types.IElement, parent types.IElement, val any) error {
+			e.(*types.Cell).Href = strings.TrimSpace(val.(string))
+			return nil
+		},
+		"cell.link-to": func(e types.IElement, parent types.IElement, val any) error {
+			name := strings.TrimSpace(val.(string))
+			if name == "" {
+				return errors.New("missing anchor name")
+			}
+			e.(*types.Cell).Href = "#" + name
+			return nil
+		},
+		"cell.anchor": func(e types.IElement, parent types.IElement, val any) error {
+			e.(*types.Cell).Anchor = strings.TrimSpace(val.(string))
+			return nil
+		},
+		"cell.bookmark": func(e types.IElement, parent types.IElement, val any) error {
+			e.(*types.Cell).BookmarkTitle = val.(string)
+			return nil
+		},
 		"cell.width": func(e types.IElement, parent types.IElement, val any) error {
 			cell := e.(*types.Cell)
 			if cell.Width == nil {
@@ -194,6 +481,257 @@ var (
 			cell.Absolute, err = strconv.ParseBool(val.(string))
 			return err
 		},
+		"cell.repeat": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			if _, err := expr.CompileRepeat(val.(string)); err != nil {
+				return err
+			}
+			cell.Repeat = val.(string)
+			return nil
+		},
+		"cell.colspan": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			span, err := spanFn(val.(string))
+			cell.ColSpan = span
+			return err
+		},
+		"cell.rowspan": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			span, err := spanFn(val.(string))
+			cell.RowSpan = span
+			return err
+		},
+		"cell.layout": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).Layout.Parse(val.(string))
+		},
+		"cell.flex-direction": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).Flex.Direction.Parse(val.(string))
+		},
+		"cell.flex-wrap": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			switch strings.ToLower(val.(string)) {
+			case "wrap":
+				cell.Flex.Wrap = true
+			case "nowrap":
+				cell.Flex.Wrap = false
+			default:
+				return errors.Errorf("invalid flex wrap `%s`", val)
+			}
+			return nil
+		},
+		"cell.justify-content": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).Flex.Justify.Parse(val.(string))
+		},
+		"cell.align-items": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).Flex.AlignItems.Parse(val.(string))
+		},
+		"cell.gap": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			if cell.Gap == nil {
+				cell.Gap = new(types.Dimension)
+			}
+			return cell.Gap.UnmarshalText([]byte(val.(string)))
+		},
+		"cell.flex-grow": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			grow, err := strconv.ParseFloat(val.(string), 64)
+			if err != nil || grow < 0 {
+				return errors.Errorf("invalid flex grow `%s`", val)
+			}
+			cell.Grow = grow
+			return nil
+		},
+		"cell.flex-shrink": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			shrink, err := strconv.ParseFloat(val.(string), 64)
+			if err != nil || shrink < 0 {
+				return errors.Errorf("invalid flex shrink `%s`", val)
+			}
+			cell.Shrink = &shrink
+			return nil
+		},
+		"cell.grid-template-columns": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			cell := e.(*types.Cell)
+			cell.Grid.Columns, err = types.ParseGridTracks(val.(string))
+			return err
+		},
+		"cell.grid-template-rows": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			cell := e.(*types.Cell)
+			cell.Grid.Rows, err = types.ParseGridTracks(val.(string))
+			return err
+		},
+		"cell.grid-template-areas": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			cell := e.(*types.Cell)
+			cell.Grid.Areas, err = types.ParseGridAreas(val.(string))
+			return err
+		},
+		"cell.grid-column": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			item := &e.(*types.Cell).GridItem
+			item.ColumnStart, item.ColumnSpan, err = types.ParseGridLine(val.(string))
+			return err
+		},
+		"cell.grid-row": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			item := &e.(*types.Cell).GridItem
+			item.RowStart, item.RowSpan, err = types.ParseGridLine(val.(string))
+			return err
+		},
+		"cell.grid-area": func(e types.IElement, parent types.IElement, val any) error {
+			name := strings.TrimSpace(val.(string))
+			if name == "" || strings.ContainsAny(name, " /") {
+				return errors.Errorf("invalid grid area `%s`", val)
+			}
+			e.(*types.Cell).GridItem.Area = name
+			return nil
+		},
+		"cell.image": func(e types.IElement, parent types.IElement, val any) error {
+			src := strings.TrimSpace(val.(string))
+			if src == "" {
+				return errors.New("missing image source")
+			}
+			e.(*types.Cell).Image = src
+			return nil
+		},
+		"cell.image-fit": func(e types.IElement, parent types.IElement, val any) error {
+			return e.(*types.Cell).ImageStyle.Fit.Parse(val.(string))
+		},
+		"cell.image-align": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			table := []rune("LCRTMB")
+			values := strings.ToUpper(val.(string))
+			for _, r := range values {
+				if !slices.Contains(table, r) {
+					return errors.Errorf("invalid image alignment flag `%c`. expected any of `LCRTMB`", r)
+				}
+			}
+			cell.ImageStyle.Alignment = values
+			return nil
+		},
+		"cell.image-alpha": func(e types.IElement, parent types.IElement, val any) error {
+			cell := e.(*types.Cell)
+			alpha, err := strconv.ParseFloat(val.(string), 64)
+			if err != nil || alpha < 0 || alpha > 1 {
+				return errors.Errorf("invalid image alpha `%s`, expected a value between 0 and 1", val)
+			}
+			cell.ImageStyle.Alpha = &alpha
+			return nil
+		},
+		"toc.leader": func(e types.IElement, parent types.IElement, val any) error {
+			if val.(string) == "" {
+				return errors.New("empty leader")
+			}
+			e.(*types.Toc).Leader = val.(string)
+			return nil
+		},
+		"toc.indent": func(e types.IElement, parent types.IElement, val any) error {
+			toc := e.(*types.Toc)
+			if toc.Indent == nil {
+				toc.Indent = new(types.Dimension)
+			}
+			return toc.Indent.UnmarshalText([]byte(val.(string)))
+		},
+		"table.width": func(e types.IElement, parent types.IElement, val any) error {
+			table := e.(*types.Table)
+			if table.Width == nil {
+				table.Width = new(types.Dimension)
+			}
+			return table.Width.UnmarshalText([]byte(val.(string)))
+		},
+		"table.repeat-header": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			table := e.(*types.Table)
+			table.RepeatHeader, err = strconv.ParseBool(val.(string))
+			return err
+		},
+		"row.height": func(e types.IElement, parent types.IElement, val any) error {
+			row := e.(*types.TableRow)
+			if row.Height == nil {
+				row.Height = new(types.Dimension)
+			}
+			return row.Height.UnmarshalText([]byte(val.(string)))
+		},
+		"row.repeat": func(e types.IElement, parent types.IElement, val any) error {
+			row := e.(*types.TableRow)
+			if _, err := expr.CompileRepeat(val.(string)); err != nil {
+				return err
+			}
+			row.Repeat = val.(string)
+			return nil
+		},
+		"cell.bookmark-level":    bookmarkLevelFn,
+		"page.bookmark-level":    bookmarkLevelFn,
+		"section.bookmark-level": bookmarkLevelFn,
+		"document.margin":        spacingFn(marginsFn),
+		"document.margin-left":   spacingSideFn(marginsFn, leftFn),
+		"document.margin-top":    spacingSideFn(marginsFn, topFn),
+		"document.margin-right":  spacingSideFn(marginsFn, rightFn),
+		"document.margin-bottom": spacingSideFn(marginsFn, bottomFn),
+		"page.margin":            spacingFn(marginsFn),
+		"page.margin-left":       spacingSideFn(marginsFn, leftFn),
+		"page.margin-top":        spacingSideFn(marginsFn, topFn),
+		"page.margin-right":      spacingSideFn(marginsFn, rightFn),
+		"page.margin-bottom":     spacingSideFn(marginsFn, bottomFn),
+		"section.margin":         spacingFn(marginsFn),
+		"section.margin-left":    spacingSideFn(marginsFn, leftFn),
+		"section.margin-top":     spacingSideFn(marginsFn, topFn),
+		"section.margin-right":   spacingSideFn(marginsFn, rightFn),
+		"section.margin-bottom":  spacingSideFn(marginsFn, bottomFn),
+		"cell.margin":            spacingFn(marginsFn),
+		"cell.margin-left":       spacingSideFn(marginsFn, leftFn),
+		"cell.margin-top":        spacingSideFn(marginsFn, topFn),
+		"cell.margin-right":      spacingSideFn(marginsFn, rightFn),
+		"cell.margin-bottom":     spacingSideFn(marginsFn, bottomFn),
+		"cell.padding":           spacingFn(paddingsFn),
+		"cell.padding-left":      spacingSideFn(paddingsFn, leftFn),
+		"cell.padding-top":       spacingSideFn(paddingsFn, topFn),
+		"cell.padding-right":     spacingSideFn(paddingsFn, rightFn),
+		"cell.padding-bottom":    spacingSideFn(paddingsFn, bottomFn),
+		"page.padding":           spacingFn(paddingsFn),
+		"page.padding-left":      spacingSideFn(paddingsFn, leftFn),
+		"page.padding-top":       spacingSideFn(paddingsFn, topFn),
+		"page.padding-right":     spacingSideFn(paddingsFn, rightFn),
+		"page.padding-bottom":    spacingSideFn(paddingsFn, bottomFn),
+		"header.padding":         spacingFn(paddingsFn),
+		"header.padding-left":    spacingSideFn(paddingsFn, leftFn),
+		"header.padding-top":     spacingSideFn(paddingsFn, topFn),
+		"header.padding-right":   spacingSideFn(paddingsFn, rightFn),
+		"header.padding-bottom":  spacingSideFn(paddingsFn, bottomFn),
+		"footer.padding":         spacingFn(paddingsFn),
+		"footer.padding-left":    spacingSideFn(paddingsFn, leftFn),
+		"footer.padding-top":     spacingSideFn(paddingsFn, topFn),
+		"footer.padding-right":   spacingSideFn(paddingsFn, rightFn),
+		"footer.padding-bottom":  spacingSideFn(paddingsFn, bottomFn),
+		"section.page-number": func(e types.IElement, parent types.IElement, val any) error {
+			section := e.(*types.Section)
+			number, err := strconv.Atoi(val.(string))
+			if err != nil || number < 1 {
+				return errors.Errorf("invalid page number `%s`, expected a positive number", val)
+			}
+			section.PageNumber = number
+			return nil
+		},
+		"page.flow": func(e types.IElement, parent types.IElement, val any) error {
+			var err error
+			page := e.(*types.Page)
+			page.Flow, err = strconv.ParseBool(val.(string))
+			return err
+		},
+		"header.height":        sectionHeightFn,
+		"header.margin":        sectionMarginFn,
+		"header.margin-left":   spacingSideFn(sectionMarginsFn, leftFn),
+		"header.margin-top":    spacingSideFn(sectionMarginsFn, topFn),
+		"header.margin-right":  spacingSideFn(sectionMarginsFn, rightFn),
+		"header.margin-bottom": spacingSideFn(sectionMarginsFn, bottomFn),
+		"footer.height":        sectionHeightFn,
+		"footer.margin":        sectionMarginFn,
+		"footer.margin-left":   spacingSideFn(sectionMarginsFn, leftFn),
+		"footer.margin-top":    spacingSideFn(sectionMarginsFn, topFn),
+		"footer.margin-right":  spacingSideFn(sectionMarginsFn, rightFn),
+		"footer.margin-bottom": spacingSideFn(sectionMarginsFn, bottomFn),
 		"cell.display": func(e types.IElement, parent types.IElement, val any) error {
 			cell := e.(*types.Cell)
 			return cell.TextStyle.DisplayStyle.Parse(val.(string))
@@ -19,6 +19,16 @@ func (c *elementCell[T, P]) Text(text string) T {
 	return c.self
 }
 
+func (c *elementCell[T, P]) Span(text string, attrs types.PdfTemplateAttributes, styles ...string) T {
+	span := &types.Span{Text: text}
+	span.StyleList = styles
+	for name, value := range attrs {
+		span.Attrs = append(span.Attrs, &types.Attribute{Name: name, Value: value})
+	}
+	c.cell.Spans = append(c.cell.Spans, span)
+	return c.self
+}
+
 func (c *elementCell[T, P]) Attribute(name, value string) T {
 	c.container.Attribute(name, value)
 	return c.self
@@ -36,3 +46,36 @@ func (c *elementCell[T, P]) StyleList(name string, more ...string) T {
 	c.cell.StyleList = append(c.cell.StyleList, more...)
 	return c.self
 }
+
+func (c *elementCell[T, P]) Repeat(expression string) T {
+	c.container.Attribute("repeat", expression)
+	return c.self
+}
+
+func (c *elementCell[T, P]) Builder() types.PdfTemplateBuilder {
+	return c.builder
+}
+
+func (c *elementCell[T, P]) AddCell() types.PdfTemplateChildCell {
+	return newChildCell(any(c.self).(types.PdfTemplateCellContainer), c.cell, c.builder)
+}
+
+type childCell struct {
+	elementCell[types.PdfTemplateChildCell, types.PdfTemplateCellContainer]
+}
+
+func newChildCell(parent types.PdfTemplateCellContainer, parentCell *types.Cell, builder *BuilderImpl) *childCell {
+	var newCell types.Cell
+	parentCell.Cells = append(parentCell.Cells, &newCell)
+	cell := &childCell{
+		elementCell[types.PdfTemplateChildCell, types.PdfTemplateCellContainer]{
+			parent:  parent,
+			cell:    &newCell,
+			builder: builder,
+		},
+	}
+	cell.self = cell
+	cell.container.builder = builder
+	cell.container.attributes = &newCell.Attrs
+	return cell
+}
@@ -18,6 +18,7 @@ func (h *headerImpl) AddCell() types.PdfTemplateHeaderCell {
 		elementCell[types.PdfTemplateHeaderCell, types.PdfTemplateHeader]{
 			self:    nil,
 			parent:  h,
+			cell:    &newCell,
 			builder: h.container.builder,
 		},
 	}
@@ -0,0 +1,432 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Cell of a container resolved against the data. The rect holds the position relative to the parent container
+// in Left/Top and the size in Right/Bottom, margins of the cell included.
+type layoutBox struct {
+	cell            *types.Cell // styled cell with its text resolved
+	scope           *expr.Scope // scope the cell was resolved in, for its bookmark
+	children        []*layoutBox
+	rect            types.Rect
+	margin, padding types.Margins
+}
+
+func (b *layoutBox) isContainer() bool {
+	return b.children != nil
+}
+
+// Resolves a container and its children against the data
+func (r *renderer) expandBox(cell *types.Cell, ctx *cellContext, scope *expr.Scope) (*layoutBox, error) {
+	styled, err := r.styleCell(cell, scope)
+	if err != nil {
+		return nil, err
+	}
+	if styled.Href != "" || styled.Anchor != "" {
+		linked := *styled
+		if linked.Href, linked.Anchor, err = r.resolveLink(styled, scope); err != nil {
+			return nil, err
+		}
+		styled = &linked
+	}
+	b := &layoutBox{cell: styled, scope: scope, children: []*layoutBox{}}
+	if err := r.expandChildren(&b.children, cell.Cells, ctx, scope); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Expands repeated cells, drops the cells whose condition does not hold and flattens groups
+func (r *renderer) expandChildren(dst *[]*layoutBox, cells []*types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	for i, cell := range cells {
+		scopes, err := r.repeatScopes(cell.Repeat, scope)
+		if err != nil {
+			return errors.Wrapf(err, "cell %d", i)
+		}
+		for _, s := range scopes {
+			visible, err := r.test(cell.Condition, s)
+			if err != nil {
+				return errors.Wrapf(err, "cell %d", i)
+			}
+			if !visible {
+				continue
+			}
+			switch {
+			case cell.Table != nil:
+				return errors.Errorf("cell %d: tables are not supported inside containers", i)
+			case cell.Toc != nil:
+				return errors.Errorf("cell %d: tables of contents are not supported inside containers", i)
+			case cell.IsContainer():
+				b, err := r.expandBox(cell, ctx, s)
+				if err != nil {
+					return errors.Wrapf(err, "cell %d", i)
+				}
+				*dst = append(*dst, b)
+			case len(cell.Cells) > 0:
+				if err := r.expandChildren(dst, cell.Cells, ctx, s); err != nil {
+					return errors.Wrapf(err, "cell %d", i)
+				}
+			default:
+				styled, err := r.styleCell(cell, s)
+				if err != nil {
+					return errors.Wrapf(err, "cell %d", i)
+				}
+				resolved, err := r.resolveCell(styled, s)
+				if err != nil {
+					return errors.Wrapf(err, "cell %d", i)
+				}
+				*dst = append(*dst, &layoutBox{cell: resolved, scope: s})
+			}
+		}
+	}
+	return nil
+}
+
+// Sets the size of the box. Containers without a width take the width of their parent, and the height of their
+// content when they have no height. Padding is part of the size, and the margins are added around it.
+func (r *renderer) measureBox(c types.Canvas, b *layoutBox, parent *types.Rect) {
+	b.margin, b.padding = b.cell.GetSpacing(c, r.doc, parent)
+	padw, padh := b.padding.Left+b.padding.Right, b.padding.Top+b.padding.Bottom
+	marginw, marginh := b.margin.Left+b.margin.Right, b.margin.Top+b.margin.Bottom
+	if !b.isContainer() {
+		w, h := b.cell.GetSize(c, r.doc, parent)
+		if b.cell.ImageData == nil {
+			// measure text with the font of the cell
+			textW, textH := b.cell.MeasureText(c, math.Inf(1))
+			if b.cell.Width == nil {
+				w = textW + padw
+				if b.cell.TextStyle.Wrap {
+					w = min(w, parent.Right-marginw)
+				}
+			}
+			if b.cell.Height == nil {
+				h = textH + padh
+			}
+		}
+		b.rect.Right, b.rect.Bottom = w+marginw, h+marginh
+		if wrappedH, ok := r.wrappedHeight(c, b, b.rect.Right); ok {
+			b.rect.Bottom = wrappedH
+		}
+		return
+	}
+	w, h := b.cell.GetSize(c, r.doc, parent)
+	if b.cell.Width == nil {
+		w = parent.Right - marginw
+	}
+	inner := types.Rect{Right: w - padw, Bottom: h - padh}
+	if b.cell.Height == nil {
+		inner.Bottom = parent.Bottom - marginh - padh
+	}
+	contentH := r.arrange(c, b, &inner)
+	if b.cell.Height == nil {
+		h = contentH + padh
+	}
+	b.rect.Right, b.rect.Bottom = w+marginw, h+marginh
+}
+
+// Returns the height of a box with wrapped text and no height laid out at width w, margins included.
+// ok is false for containers, images and boxes with a height.
+func (r *renderer) wrappedHeight(c types.Canvas, b *layoutBox, w float64) (h float64, ok bool) {
+	cell := b.cell
+	if b.isContainer() || !cell.TextStyle.Wrap || cell.Height != nil || cell.ImageData != nil {
+		return 0, false
+	}
+	w -= b.margin.Left + b.margin.Right + b.padding.Left + b.padding.Right
+	_, h = cell.MeasureText(c, w)
+	return h + b.margin.Top + b.margin.Bottom + b.padding.Top + b.padding.Bottom, true
+}
+
+// Positions the children of the container according to its layout and returns the height of the content
+func (r *renderer) arrange(c types.Canvas, b *layoutBox, inner *types.Rect) float64 {
+	switch b.cell.Layout {
+	case types.LM_FLEX:
+		return r.arrangeFlex(c, b, inner)
+	case types.LM_GRID:
+		return r.arrangeGrid(c, b, inner)
+	default:
+		return r.arrangeFlow(c, b, inner)
+	}
+}
+
+// Sets the size the layout of the parent gave the box. Containers arrange their children again.
+func (r *renderer) resizeBox(c types.Canvas, b *layoutBox, w, h float64) {
+	if b.rect.Right == w && b.rect.Bottom == h {
+		return
+	}
+	b.rect.Right, b.rect.Bottom = w, h
+	if b.isContainer() {
+		inner := b.padding.Inset(b.margin.Inset(types.Rect{Right: w, Bottom: h}))
+		r.arrange(c, b, &types.Rect{Right: inner.Right, Bottom: inner.Bottom})
+	}
+}
+
+// Child of a flex container, sized along the main and cross axes
+type flexItem struct {
+	b           *layoutBox
+	main, cross float64
+}
+
+// Positions the children of a flex container and returns the height of the content.
+// Children are placed in lines along the main axis, then grown or shrunk to fill each line according to their
+// flex factors, distributed according to justify-content and aligned on the cross axis according to align-items.
+func (r *renderer) arrangeFlex(c types.Canvas, b *layoutBox, inner *types.Rect) float64 {
+	flex := &b.cell.Flex
+	row := flex.IsRow()
+
+	// column containers without a height have no main size to fill
+	mainSize, crossSize := inner.Right, inner.Bottom
+	definiteMain, definiteCross := true, b.cell.Height != nil
+	gapFlags := types.UT_LENGTH | types.UT_LENGH_WIDTH
+	if !row {
+		mainSize, crossSize = inner.Bottom, inner.Right
+		definiteMain, definiteCross = b.cell.Height != nil, true
+		gapFlags = types.UT_LENGTH | types.UT_LENGTH_HEIGHT
+	}
+	gap := 0.
+	if b.cell.Gap != nil {
+		gap = b.cell.Gap.GetValue(inner.Right, inner.Bottom, 0, gapFlags, r.doc.DisplayUnit)
+	}
+
+	lines := [][]*flexItem{}
+	lineMain := 0.
+	for _, child := range b.children {
+		r.measureBox(c, child, inner)
+		if child.cell.Absolute {
+			child.rect.Left, child.rect.Top = child.cell.Left, child.cell.Top
+			continue
+		}
+		it := &flexItem{b: child, main: child.rect.Right, cross: child.rect.Bottom}
+		if !row {
+			it.main, it.cross = it.cross, it.main
+		}
+		if len(lines) == 0 || (flex.Wrap && definiteMain && lineMain+gap+it.main > mainSize) {
+			lines = append(lines, []*flexItem{})
+			lineMain = it.main
+		} else {
+			lineMain += gap + it.main
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], it)
+	}
+
+	crossPos, contentMain := 0., 0.
+	for li, line := range lines {
+		used := gap * float64(len(line)-1)
+		for _, it := range line {
+			used += it.main
+		}
+		free := 0.
+		if definiteMain {
+			free = mainSize - used
+		}
+
+		// grow or shrink to fill the line
+		if free > 0 {
+			grow := 0.
+			for _, it := range line {
+				grow += it.b.cell.Grow
+			}
+			if grow > 0 {
+				for _, it := range line {
+					it.main += free * it.b.cell.Grow / grow
+				}
+				used, free = mainSize, 0
+			}
+		} else if free < 0 {
+			shrink := 0.
+			for _, it := range line {
+				shrink += it.b.cell.GetShrink() * it.main
+			}
+			if shrink > 0 {
+				for _, it := range line {
+					it.main = max(it.main+free*it.b.cell.GetShrink()*it.main/shrink, 0)
+				}
+				used, free = mainSize, 0
+			}
+		}
+
+		start, between := 0., gap
+		if n := float64(len(line)); free > 0 {
+			switch flex.Justify {
+			case types.FJ_END:
+				start = free
+			case types.FJ_CENTER:
+				start = free / 2
+			case types.FJ_SPACE_BETWEEN:
+				if n > 1 {
+					between += free / (n - 1)
+				}
+			case types.FJ_SPACE_AROUND:
+				start, between = free/n/2, between+free/n
+			case types.FJ_SPACE_EVENLY:
+				start, between = free/(n+1), between+free/(n+1)
+			}
+		}
+
+		if row {
+			// wrapped text flows again in the width it was given
+			for _, it := range line {
+				if h, ok := r.wrappedHeight(c, it.b, it.main); ok {
+					it.cross = h
+				}
+			}
+		}
+		lineCross := 0.
+		for _, it := range line {
+			lineCross = max(lineCross, it.cross)
+		}
+		if len(lines) == 1 && definiteCross {
+			lineCross = crossSize
+		}
+
+		extent := used
+		if definiteMain {
+			extent = mainSize
+		}
+		pos := start
+		for _, it := range line {
+			cross, offset := it.cross, 0.
+			switch flex.AlignItems {
+			case types.FA_START:
+			case types.FA_END:
+				offset = lineCross - it.cross
+			case types.FA_CENTER:
+				offset = (lineCross - it.cross) / 2
+			default:
+				// stretch children without an explicit size on the cross axis
+				if (row && it.b.cell.Height == nil) || (!row && it.b.cell.Width == nil) {
+					cross = lineCross
+				}
+			}
+			mainPos := pos
+			if flex.IsReverse() {
+				mainPos = extent - pos - it.main
+			}
+			if row {
+				it.b.rect.Left, it.b.rect.Top = mainPos, crossPos+offset
+				r.resizeBox(c, it.b, it.main, cross)
+			} else {
+				it.b.rect.Left, it.b.rect.Top = crossPos+offset, mainPos
+				r.resizeBox(c, it.b, cross, it.main)
+			}
+			pos += it.main + between
+		}
+
+		contentMain = max(contentMain, used)
+		crossPos += lineCross
+		if li < len(lines)-1 {
+			crossPos += gap
+		}
+	}
+
+	if row {
+		return crossPos
+	}
+	return contentMain
+}
+
+// Positions the children of the container following their display style and returns the height of the content.
+// Absolutely positioned children are placed relative to the container and take no room.
+func (r *renderer) arrangeFlow(c types.Canvas, b *layoutBox, inner *types.Rect) float64 {
+	x, y, lineH := 0., 0., 0.
+	for _, child := range b.children {
+		r.measureBox(c, child, inner)
+		if child.cell.Absolute {
+			child.rect.Left, child.rect.Top = child.cell.Left, child.cell.Top
+			continue
+		}
+		child.rect.Left, child.rect.Top = x, y
+		w, h := child.rect.Right, child.rect.Bottom
+		switch child.cell.TextStyle.DisplayStyle {
+		case types.DISPLAY_ROW:
+			x, y, lineH = 0, y+max(lineH, h), 0
+		case types.DISPLAY_STACK:
+			y += h
+		default:
+			x += w
+			lineH = max(lineH, h)
+		}
+	}
+	return y + lineH
+}
+
+// Draws the box at x, y, then its children. Boxes with a bookmark are bookmarked at the top of their border box.
+func (r *renderer) drawBox(c types.Canvas, b *layoutBox, x, y float64, ctx *cellContext) error {
+	box := b.margin.Inset(types.Rect{Left: x, Top: y, Right: b.rect.Right, Bottom: b.rect.Bottom})
+	if err := r.bookmarkCell(b.cell, ctx, b.scope, box.Top); err != nil {
+		return err
+	}
+	if !b.isContainer() {
+		// the margins are resolved already, the cell only takes its padding into account
+		cell := *b.cell
+		cell.Width = types.NewDimension(box.Right, r.doc.DisplayUnit)
+		cell.Height = types.NewDimension(box.Bottom, r.doc.DisplayUnit)
+		cell.Padding = types.Spacing{
+			Left:   types.NewDimension(b.padding.Left, r.doc.DisplayUnit),
+			Top:    types.NewDimension(b.padding.Top, r.doc.DisplayUnit),
+			Right:  types.NewDimension(b.padding.Right, r.doc.DisplayUnit),
+			Bottom: types.NewDimension(b.padding.Bottom, r.doc.DisplayUnit),
+		}
+		if b.cell.Padding.IsZero() {
+			cell.Padding = types.Spacing{}
+		}
+		cell.Margin = types.Spacing{}
+		cell.Absolute = false
+		c.SetXY(box.Left, box.Top)
+		cell.Render(c, 0, r.doc, ctx.page, false)
+		return nil
+	}
+	if b.cell.Background != nil {
+		c.DrawRect(box, b.cell.Background)
+	}
+	b.cell.DrawLink(c, box)
+	inner := b.padding.Inset(box)
+	for _, child := range b.children {
+		if err := r.drawBox(c, child, inner.Left+child.rect.Left, inner.Top+child.rect.Top, ctx); err != nil {
+			return err
+		}
+	}
+	b.cell.DrawBorder(c, box.Left, box.Top, box.Left+box.Right, box.Top+box.Bottom)
+	return nil
+}
+
+// Renders a container, then moves the cursor past it like a cell with the same display style
+func (r *renderer) renderContainer(c types.Canvas, cell *types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	b, err := r.expandBox(cell, ctx, scope)
+	if err != nil {
+		return err
+	}
+	dc := c.GetDrawingRect()
+	r.measureBox(c, b, dc)
+
+	if ctx.beforeCell != nil {
+		ctx.beforeCell()
+	}
+	if ctx.isPageCell && ctx.page.Flow && !cell.Absolute {
+		if err := r.ensureSpace(c, ctx, b.rect.Bottom); err != nil {
+			return err
+		}
+	}
+	x, y := c.GetXY()
+	if ctx.isPageCell && cell.Absolute {
+		x, y = cell.Left, cell.Top
+	}
+	if err := r.drawBox(c, b, x, y, ctx); err != nil {
+		return err
+	}
+
+	switch b.cell.TextStyle.DisplayStyle {
+	case types.DISPLAY_ROW:
+		c.SetXY(dc.Left, y+b.rect.Bottom)
+	case types.DISPLAY_STACK:
+		c.SetXY(x, y+b.rect.Bottom)
+	default:
+		c.SetXY(x+b.rect.Right, y)
+	}
+	return nil
+}
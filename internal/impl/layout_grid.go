@@ -0,0 +1,230 @@
+package impl
+
+import (
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+)
+
+// Child of a grid container and the tracks it occupies. Rows and columns are zero-based, -1 when not placed yet.
+type gridItem struct {
+	b                *layoutBox
+	row, col         int
+	rowSpan, colSpan int
+}
+
+// Grid slots taken by the children placed so far
+type gridSlots [][]bool
+
+func (s gridSlots) isFree(it *gridItem, row, col int) bool {
+	for r := row; r < row+it.rowSpan && r < len(s); r++ {
+		for c := col; c < col+it.colSpan && c < len(s[r]); c++ {
+			if s[r][c] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *gridSlots) occupy(it *gridItem) {
+	for r := it.row; r < it.row+it.rowSpan; r++ {
+		for len(*s) <= r {
+			*s = append(*s, []bool{})
+		}
+		for c := it.col; c < it.col+it.colSpan; c++ {
+			for len((*s)[r]) <= c {
+				(*s)[r] = append((*s)[r], false)
+			}
+			(*s)[r][c] = true
+		}
+	}
+}
+
+// Places the children on the grid. Children with a named area or explicit lines are placed first,
+// the others fill the free slots row by row. Returns the number of columns and rows.
+func placeGridItems(grid *types.GridLayout, items []*gridItem) (ncols, nrows int) {
+	ncols, nrows = max(len(grid.Columns), 1), len(grid.Rows)
+	for _, area := range grid.Areas {
+		ncols, nrows = max(ncols, area.Column+area.ColumnSpan), max(nrows, area.Row+area.RowSpan)
+	}
+	for _, it := range items {
+		if it.col >= 0 {
+			ncols = max(ncols, it.col+it.colSpan)
+		}
+		ncols = max(ncols, it.colSpan)
+	}
+
+	slots := gridSlots{}
+	for _, it := range items {
+		if it.row >= 0 && it.col >= 0 {
+			slots.occupy(it)
+		}
+	}
+	cursorRow, cursorCol := 0, 0
+	for _, it := range items {
+		switch {
+		case it.row >= 0 && it.col >= 0:
+			continue
+		case it.col >= 0:
+			it.row = 0
+			for !slots.isFree(it, it.row, it.col) {
+				it.row++
+			}
+		case it.row >= 0:
+			it.col = 0
+			for it.col+it.colSpan < ncols && !slots.isFree(it, it.row, it.col) {
+				it.col++
+			}
+		default:
+			for cursorCol+it.colSpan > ncols || !slots.isFree(it, cursorRow, cursorCol) {
+				if cursorCol++; cursorCol+it.colSpan > ncols {
+					cursorRow, cursorCol = cursorRow+1, 0
+				}
+			}
+			it.row, it.col = cursorRow, cursorCol
+			cursorCol += it.colSpan
+		}
+		slots.occupy(it)
+	}
+	for _, it := range items {
+		nrows = max(nrows, it.row+it.rowSpan)
+	}
+	return ncols, nrows
+}
+
+// Sizes the n tracks of one axis. Fixed and relative tracks take their size, auto tracks the size of their content,
+// and fraction tracks share the free space. Without fraction tracks, auto tracks stretch to fill the free space.
+// When the size of the axis is not definite, fraction tracks are sized like auto tracks.
+func (r *renderer) sizeGridTracks(tracks []types.GridTrack, n int, size float64, definite bool, gap float64, flags types.UnitType, content func(i int) float64) []float64 {
+	track := func(i int) types.GridTrack {
+		if i < len(tracks) {
+			return tracks[i]
+		}
+		return types.GridTrack{}
+	}
+	sizes := make([]float64, n)
+	free := size - gap*float64(n-1)
+	fr, auto := 0., []int{}
+	for i := range sizes {
+		t := track(i)
+		switch {
+		case t.Size != nil:
+			sizes[i] = t.Size.GetValue(size, size, 0, flags, r.doc.DisplayUnit)
+		case t.Fraction > 0 && definite:
+			fr += t.Fraction
+			continue
+		default:
+			sizes[i] = content(i)
+			auto = append(auto, i)
+		}
+		free -= sizes[i]
+	}
+	if !definite {
+		return sizes
+	}
+	if fr > 0 {
+		for i := range sizes {
+			if t := track(i); t.Size == nil && t.Fraction > 0 {
+				sizes[i] = max(free, 0) * t.Fraction / fr
+			}
+		}
+	} else if free > 0 && len(auto) > 0 {
+		for _, i := range auto {
+			sizes[i] += free / float64(len(auto))
+		}
+	}
+	return sizes
+}
+
+// Returns the start offset of every track
+func trackOffsets(sizes []float64, gap float64) []float64 {
+	offsets := make([]float64, len(sizes))
+	for i := 1; i < len(sizes); i++ {
+		offsets[i] = offsets[i-1] + sizes[i-1] + gap
+	}
+	return offsets
+}
+
+// Positions the children of a grid container and returns the height of the content.
+// Children stretch over the tracks they occupy unless they have an explicit width or height.
+func (r *renderer) arrangeGrid(c types.Canvas, b *layoutBox, inner *types.Rect) float64 {
+	grid := &b.cell.Grid
+	gap := 0.
+	if b.cell.Gap != nil {
+		gap = b.cell.Gap.GetValue(inner.Right, inner.Bottom, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+	}
+
+	items := []*gridItem{}
+	for _, child := range b.children {
+		r.measureBox(c, child, inner)
+		if child.cell.Absolute {
+			child.rect.Left, child.rect.Top = child.cell.Left, child.cell.Top
+			continue
+		}
+		p := child.cell.GridItem
+		it := &gridItem{b: child, row: p.RowStart - 1, col: p.ColumnStart - 1, rowSpan: max(p.RowSpan, 1), colSpan: max(p.ColumnSpan, 1)}
+		if area, ok := grid.Areas[p.Area]; ok {
+			it.row, it.col, it.rowSpan, it.colSpan = area.Row, area.Column, area.RowSpan, area.ColumnSpan
+		}
+		items = append(items, it)
+	}
+	ncols, nrows := placeGridItems(grid, items)
+
+	widths := r.sizeGridTracks(grid.Columns, ncols, inner.Right, true, gap, types.UT_LENGTH|types.UT_LENGH_WIDTH, func(col int) (w float64) {
+		for _, it := range items {
+			if it.col == col && it.colSpan == 1 {
+				w = max(w, it.b.rect.Right)
+			}
+		}
+		return
+	})
+	xs := trackOffsets(widths, gap)
+	spanSize := func(offsets, sizes []float64, start, span int) float64 {
+		end := start + span - 1
+		return offsets[end] + sizes[end] - offsets[start]
+	}
+
+	// measure again now that the width of every child is known
+	for _, it := range items {
+		r.measureBox(c, it.b, &types.Rect{Right: spanSize(xs, widths, it.col, it.colSpan), Bottom: inner.Bottom})
+		if it.b.cell.Width == nil {
+			// wrapped text flows in the width of its columns
+			if h, ok := r.wrappedHeight(c, it.b, spanSize(xs, widths, it.col, it.colSpan)); ok {
+				it.b.rect.Bottom = h
+			}
+		}
+	}
+	heights := r.sizeGridTracks(grid.Rows, nrows, inner.Bottom, b.cell.Height != nil, gap, types.UT_LENGTH|types.UT_LENGTH_HEIGHT, func(row int) (h float64) {
+		for _, it := range items {
+			if it.row == row && it.rowSpan == 1 {
+				h = max(h, it.b.rect.Bottom)
+			}
+		}
+		return
+	})
+	for _, it := range items {
+		// children spanning several rows grow the last row they span when they need more room
+		if it.rowSpan > 1 {
+			if h := spanSize(trackOffsets(heights, gap), heights, it.row, it.rowSpan); it.b.rect.Bottom > h {
+				heights[it.row+it.rowSpan-1] += it.b.rect.Bottom - h
+			}
+		}
+	}
+	ys := trackOffsets(heights, gap)
+
+	for _, it := range items {
+		w, h := spanSize(xs, widths, it.col, it.colSpan), spanSize(ys, heights, it.row, it.rowSpan)
+		if it.b.cell.Width != nil {
+			w = it.b.rect.Right
+		}
+		if it.b.cell.Height != nil {
+			h = it.b.rect.Bottom
+		}
+		it.b.rect.Left, it.b.rect.Top = xs[it.col], ys[it.row]
+		r.resizeBox(c, it.b, w, h)
+	}
+
+	if nrows == 0 {
+		return 0
+	}
+	return ys[nrows-1] + heights[nrows-1]
+}
@@ -0,0 +1,296 @@
+package impl_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const line = 4.2333 // height of a line of the default font
+
+// Asserts the boxes of the texts, to a hundredth of a millimeter
+func assertBoxes(t *testing.T, want map[string]types.Rect, rec *recorder) {
+	for text, box := range want {
+		got, ok := rec.boxes[text]
+		if !assert.True(t, ok, "`%s` not drawn", text) {
+			continue
+		}
+		assert.InDelta(t, box.Left, got.Left, .01, "left of `%s`", text)
+		assert.InDelta(t, box.Top, got.Top, .01, "top of `%s`", text)
+		assert.InDelta(t, box.Right, got.Right, .01, "width of `%s`", text)
+		assert.InDelta(t, box.Bottom, got.Bottom, .01, "height of `%s`", text)
+	}
+}
+
+func TestContainers(t *testing.T) {
+	row := types.PdfTemplateAttributes{"width": "50%", "height": "8mm", "display": "row"}
+	tests := []struct {
+		name  string
+		card  types.PdfTemplateAttributes
+		boxes map[string]types.Rect
+	}{
+		{
+			name: "inline",
+			card: types.PdfTemplateAttributes{"width": "50%", "padding": "2mm"},
+			boxes: map[string]types.Rect{
+				"Bill to": {Left: 12, Top: 12, Right: 45.5, Bottom: 8},
+				"ACME":    {Left: 12, Top: 20, Right: 45.5, Bottom: 8},
+				"Next":    {Left: 105, Top: 10, Right: 20, Bottom: 8},
+			},
+		},
+		{
+			name: "row",
+			card: types.PdfTemplateAttributes{"width": "50%", "height": "30mm", "display": "row"},
+			boxes: map[string]types.Rect{
+				"Bill to": {Left: 10, Top: 10, Right: 47.5, Bottom: 8},
+				"ACME":    {Left: 10, Top: 18, Right: 47.5, Bottom: 8},
+				"Next":    {Left: 10, Top: 40, Right: 20, Bottom: 8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			p := b.AddPage()
+			card := p.AddCell().Attributes(tt.card)
+			card.AddCell().Text("Bill to").Attributes(row)
+			card.AddCell().Text("ACME").Attributes(row)
+			p.AddCell().Text("Next").Attributes(types.PdfTemplateAttributes{"width": "20mm", "height": "8mm"})
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestFlexLayout(t *testing.T) {
+	item := func(w string) types.PdfTemplateAttributes {
+		return types.PdfTemplateAttributes{"width": w, "height": "10mm"}
+	}
+	tests := []struct {
+		name  string
+		flex  types.PdfTemplateAttributes
+		items []types.PdfTemplateAttributes
+		boxes map[string]types.Rect
+	}{
+		{
+			name:  "space between",
+			flex:  types.PdfTemplateAttributes{"justify-content": "space-between"},
+			items: []types.PdfTemplateAttributes{item("30mm"), item("40mm"), item("30mm")},
+			boxes: map[string]types.Rect{
+				"A": {Left: 10, Top: 10, Right: 30, Bottom: 10},
+				"B": {Left: 85, Top: 10, Right: 40, Bottom: 10},
+				"C": {Left: 170, Top: 10, Right: 30, Bottom: 10},
+			},
+		},
+		{
+			name:  "grow and gap",
+			flex:  types.PdfTemplateAttributes{"gap": "5mm"},
+			items: []types.PdfTemplateAttributes{item("30mm"), {"flex-grow": "1", "height": "10mm"}, item("30mm")},
+			boxes: map[string]types.Rect{
+				"A": {Left: 10, Top: 10, Right: 30, Bottom: 10},
+				"B": {Left: 45, Top: 10, Right: 120, Bottom: 10},
+				"C": {Left: 170, Top: 10, Right: 30, Bottom: 10},
+			},
+		},
+		{
+			name:  "wrap",
+			flex:  types.PdfTemplateAttributes{"flex-wrap": "wrap", "gap": "4mm"},
+			items: []types.PdfTemplateAttributes{item("80mm"), item("80mm"), item("80mm")},
+			boxes: map[string]types.Rect{
+				"A": {Left: 10, Top: 10, Right: 80, Bottom: 10},
+				"B": {Left: 94, Top: 10, Right: 80, Bottom: 10},
+				"C": {Left: 10, Top: 24, Right: 80, Bottom: 10},
+			},
+		},
+		{
+			name:  "column centered",
+			flex:  types.PdfTemplateAttributes{"flex-direction": "column", "align-items": "center"},
+			items: []types.PdfTemplateAttributes{item("30mm"), item("40mm"), item("30mm")},
+			boxes: map[string]types.Rect{
+				"A": {Left: 90, Top: 10, Right: 30, Bottom: 10},
+				"B": {Left: 85, Top: 20, Right: 40, Bottom: 10},
+				"C": {Left: 90, Top: 30, Right: 30, Bottom: 10},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			flex := b.AddPage().AddCell().Attributes(types.PdfTemplateAttributes{"layout": "flex", "display": "row"}).Attributes(tt.flex)
+			for i, attrs := range tt.items {
+				flex.AddCell().Text(string(rune('A' + i))).Attributes(attrs)
+			}
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		grid  types.PdfTemplateAttributes
+		items []types.PdfTemplateAttributes
+		boxes map[string]types.Rect
+		err   string
+	}{
+		{
+			name: "areas",
+			grid: types.PdfTemplateAttributes{
+				"grid-template-columns": "30mm 1fr 40mm",
+				"grid-template-rows":    "10mm 20mm",
+				"grid-template-areas":   `"logo title title" "logo body stamp"`,
+				"gap":                   "2mm",
+			},
+			items: []types.PdfTemplateAttributes{{"grid-area": "logo"}, {"grid-area": "title"}, {"grid-area": "body"}, {"grid-area": "stamp"}},
+			boxes: map[string]types.Rect{
+				"A": {Left: 10, Top: 10, Right: 30, Bottom: 32},
+				"B": {Left: 42, Top: 10, Right: 158, Bottom: 10},
+				"C": {Left: 42, Top: 22, Right: 116, Bottom: 20},
+				"D": {Left: 160, Top: 22, Right: 40, Bottom: 20},
+			},
+		},
+		{
+			name:  "auto placement and spans",
+			grid:  types.PdfTemplateAttributes{"grid-template-columns": "1fr 2fr", "gap": "1mm"},
+			items: []types.PdfTemplateAttributes{{"height": "10mm"}, {"height": "10mm"}, {"grid-column": "1 / span 2", "height": "10mm"}},
+			boxes: map[string]types.Rect{
+				"A": {Left: 10, Top: 10, Right: 63, Bottom: 10},
+				"B": {Left: 74, Top: 10, Right: 126, Bottom: 10},
+				"C": {Left: 10, Top: 21, Right: 190, Bottom: 10},
+			},
+		},
+		{
+			name:  "undefined area",
+			grid:  types.PdfTemplateAttributes{"grid-template-columns": "1fr", "grid-template-areas": `"body"`},
+			items: []types.PdfTemplateAttributes{{"grid-area": "footer"}},
+			err:   "error in cell 0 of page 0: child cell 0: grid area `footer` is not defined by its container",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			grid := b.AddPage().AddCell().Attributes(types.PdfTemplateAttributes{"layout": "grid", "display": "row"}).Attributes(tt.grid)
+			for i, attrs := range tt.items {
+				grid.AddCell().Text(string(rune('A' + i))).Attributes(attrs)
+			}
+			rec, err := render(t, b, nil)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestWrappedText(t *testing.T) {
+	text := "First line\nsecond line\nthird"
+	lorem := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua."
+	tests := []struct {
+		name  string
+		build func(p types.PdfTemplatePage)
+		boxes map[string]types.Rect
+	}{
+		{
+			name: "newlines",
+			build: func(p types.PdfTemplatePage) {
+				p.AddCell().Text(text).Attributes(types.PdfTemplateAttributes{"wrap": "true", "width": "60mm", "display": "row"})
+				p.AddCell().Text("Next").Attributes(types.PdfTemplateAttributes{"width": "20mm", "height": "5mm"})
+			},
+			boxes: map[string]types.Rect{
+				text:   {Left: 10, Top: 10, Right: 60, Bottom: 3 * line},
+				"Next": {Left: 10, Top: 10 + 3*line, Right: 20, Bottom: 5},
+			},
+		},
+		{
+			name: "word boundaries",
+			build: func(p types.PdfTemplatePage) {
+				p.AddCell().Text(lorem).Attributes(types.PdfTemplateAttributes{"wrap": "true", "width": "60mm", "padding": "2mm", "display": "row"})
+				p.AddCell().Text("Next").Attributes(types.PdfTemplateAttributes{"width": "20mm", "height": "5mm"})
+			},
+			boxes: map[string]types.Rect{
+				lorem:  {Left: 12, Top: 12, Right: 56, Bottom: 7 * line},
+				"Next": {Left: 10, Top: 14 + 7*line, Right: 20, Bottom: 5},
+			},
+		},
+		{
+			name: "table",
+			build: func(p types.PdfTemplatePage) {
+				table := p.AddTable().Columns("30mm", "60mm")
+				table.AddRow().AddCell().Text("Notes").Parent().AddCell().Text(text).Attribute("wrap", "true")
+				table.AddRow().AddCell().Text("Next")
+			},
+			boxes: map[string]types.Rect{
+				"Notes": {Left: 10, Top: 10, Right: 30, Bottom: 3 * line},
+				text:    {Left: 40, Top: 10, Right: 60, Bottom: 3 * line},
+				"Next":  {Left: 10, Top: 10 + 3*line, Right: 30, Bottom: line},
+			},
+		},
+		{
+			name: "flex",
+			build: func(p types.PdfTemplatePage) {
+				flex := p.AddCell().Attributes(types.PdfTemplateAttributes{"layout": "flex", "gap": "4mm", "display": "row"})
+				flex.AddCell().Text("Label").Attributes(types.PdfTemplateAttributes{"width": "20mm", "flex-shrink": "0"})
+				flex.AddCell().Text(lorem).Attributes(types.PdfTemplateAttributes{"wrap": "true", "flex-grow": "1"})
+				p.AddCell().Text("Next").Attributes(types.PdfTemplateAttributes{"width": "20mm", "height": "5mm"})
+			},
+			boxes: map[string]types.Rect{
+				"Label": {Left: 10, Top: 10, Right: 20, Bottom: 2 * line},
+				lorem:   {Left: 34, Top: 10, Right: 166, Bottom: 2 * line},
+				"Next":  {Left: 10, Top: 10 + 2*line, Right: 20, Bottom: 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b.AddPage())
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestHyphenation(t *testing.T) {
+	terms := "The Supplier shall indemnify the Customer against all liabilities, costs, expenses, damages and losses."
+	tests := []struct {
+		name  string
+		page  types.PdfTemplateAttributes
+		cell  types.PdfTemplateAttributes
+		style string
+		lines int
+		err   string
+	}{
+		{name: "off", lines: 8},
+		{name: "on", cell: types.PdfTemplateAttributes{"hyphenate": "true", "lang": "en"}, lines: 7},
+		{name: "justified", cell: types.PdfTemplateAttributes{"hyphenate": "true", "text-align": "JT"}, lines: 7},
+		{name: "from the style", style: "paragraph", lines: 7},
+		{name: "inherited", page: types.PdfTemplateAttributes{"hyphenate": "true", "lang": "en-us"}, lines: 7},
+		{name: "unknown language", cell: types.PdfTemplateAttributes{"lang": "xx"}, err: "error in cell 0 of page 0: attribute `lang`: no hyphenation patterns for language `xx`"},
+		{name: "justified without wrap", cell: types.PdfTemplateAttributes{"wrap": "false", "text-align": "J"}, err: "error in cell 0 of page 0: justified text alignment `J` requires `wrap`"},
+		{name: "alignment", cell: types.PdfTemplateAttributes{"text-align": "JX"}, err: "error in cell 0 of page 0: attribute `text-align`: invalid text alignment flag `X`. expected any of `LCRBATMJ`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.Style("paragraph", types.PdfTemplateAttributes{"hyphenate": "true", "lang": "en"})
+			cell := b.AddPage().Attributes(tt.page).AddCell().Text(terms).Attributes(types.PdfTemplateAttributes{"wrap": "true", "width": "45mm"}).Attributes(tt.cell)
+			if tt.style != "" {
+				cell.StyleList(tt.style)
+			}
+			rec, err := render(t, b, nil)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assertBoxes(t, map[string]types.Rect{terms: {Left: 10, Top: 10, Right: 45, Bottom: float64(tt.lines) * line}}, rec)
+		})
+	}
+}
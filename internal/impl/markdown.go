@@ -0,0 +1,130 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gintec-rdl/pdf-go/internal/markdown"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Attributes of the styles markdown constructs are drawn with. Document styles of the same name are applied over them.
+// Block styles take the font and `margin` attributes: the top and bottom margins separate the blocks and the left margin
+// indents them, list items by nesting level. Inline styles take the font attributes and apply over the block style.
+var markdownStyles = map[string]types.PdfTemplateAttributes{
+	"md-p":      {"margin-bottom": "2mm"},
+	"md-h1":     {"font-style": "bold", "font-size": "180%", "margin-top": "4mm", "margin-bottom": "2mm"},
+	"md-h2":     {"font-style": "bold", "font-size": "150%", "margin-top": "3mm", "margin-bottom": "2mm"},
+	"md-h3":     {"font-style": "bold", "font-size": "125%", "margin-top": "2mm", "margin-bottom": "1mm"},
+	"md-h4":     {"font-style": "bold", "margin-top": "2mm", "margin-bottom": "1mm"},
+	"md-h5":     {"font-style": "bold", "margin-top": "2mm", "margin-bottom": "1mm"},
+	"md-h6":     {"font-style": "bold", "margin-top": "2mm", "margin-bottom": "1mm"},
+	"md-li":     {"margin-left": "5mm", "margin-bottom": "1mm"},
+	"md-em":     {"font-style": "italic"},
+	"md-strong": {"font-style": "bold"},
+	"md-code":   {"font-family": "courier"},
+	"md-a":      {"font-style": "underline", "font-color": "#0645AD"},
+}
+
+// Returns the attributes of a markdown style, the document style of the same name applied over the defaults
+func (r *renderer) markdownStyle(name string) []*types.Attribute {
+	attrs := []*types.Attribute{}
+	for attr, value := range markdownStyles[name] {
+		attrs = append(attrs, &types.Attribute{Name: attr, Value: value})
+	}
+	if style, ok := r.doc.GetStyleByName(name); ok {
+		attrs = append(attrs, style.Attributes...)
+	}
+	return attrs
+}
+
+// Returns the paragraphs of the markdown text of the cell, drawn with the markdown styles
+func (r *renderer) markdownText(cell *types.Cell, text string) ([]types.TextParagraph, error) {
+	blocks := map[string]*types.Cell{}
+	blockStyle := func(name string) (*types.Cell, error) {
+		if block, ok := blocks[name]; ok {
+			return block, nil
+		}
+		// blocks are drawn with the font of the cell applied, so their size is relative to it
+		block := &types.Cell{}
+		block.Inherit(&cell.Element)
+		block.TextStyle.FontSize = *types.MustParseDimension("100%")
+		block.TextStyle.FontStyle &^= types.FS_REGULAR
+		if err := applyAttributes("cell", r.markdownStyle(name), block, cell, false); err != nil {
+			return nil, errors.Wrapf(err, "style `%s`", name)
+		}
+		blocks[name] = block
+		return block, nil
+	}
+	inlines := map[string]*types.TextBrush{}
+	inlineStyle := func(block *types.Cell, blockName string, inline markdown.Inline) (*types.TextBrush, error) {
+		names := []string{}
+		for _, s := range []struct {
+			set  bool
+			name string
+		}{{inline.Strong, "md-strong"}, {inline.Emphasis, "md-em"}, {inline.Code, "md-code"}, {inline.Link != "", "md-a"}} {
+			if s.set {
+				names = append(names, s.name)
+			}
+		}
+		if len(names) == 0 {
+			return &block.TextStyle, nil
+		}
+		key := blockName + " " + strings.Join(names, " ")
+		if brush, ok := inlines[key]; ok {
+			return brush, nil
+		}
+		span := &types.Span{}
+		span.Inherit(&block.Element)
+		for _, name := range names {
+			if err := applyAttributes("span", r.markdownStyle(name), span, block, false); err != nil {
+				return nil, errors.Wrapf(err, "style `%s`", name)
+			}
+		}
+		// relative sizes of inline styles are relative to their block
+		if size := &span.TextStyle.FontSize; *size != block.TextStyle.FontSize && size.Unit == types.DU_PERCENT {
+			size.Value *= block.TextStyle.FontSize.Value
+			size.Unit = block.TextStyle.FontSize.Unit
+		}
+		inlines[key] = &span.TextStyle
+		return &span.TextStyle, nil
+	}
+
+	parsed := markdown.Parse(text)
+	if len(parsed) == 0 {
+		// empty text takes a line, as plain text does
+		return []types.TextParagraph{{}}, nil
+	}
+	paragraphs := make([]types.TextParagraph, len(parsed))
+	for i, b := range parsed {
+		name, depth := "md-p", 1
+		switch b.Kind {
+		case markdown.Heading:
+			name = fmt.Sprintf("md-h%d", b.Level)
+		case markdown.BulletItem, markdown.NumberedItem:
+			name, depth = "md-li", b.Level+1
+		}
+		block, err := blockStyle(name)
+		if err != nil {
+			return nil, err
+		}
+		margin := block.Margin.Resolve(0, 0, types.Margins{}, r.doc.DisplayUnit)
+		p := types.TextParagraph{Indent: margin.Left * float64(depth), SpaceBefore: margin.Top, SpaceAfter: margin.Bottom}
+		switch b.Kind {
+		case markdown.BulletItem:
+			p.Marker = []types.TextRun{{Text: "-", Style: &block.TextStyle}}
+		case markdown.NumberedItem:
+			p.Marker = []types.TextRun{{Text: fmt.Sprintf("%d.", b.Number), Style: &block.TextStyle}}
+		}
+		for _, inline := range b.Inlines {
+			style, err := inlineStyle(block, name, inline)
+			if err != nil {
+				return nil, err
+			}
+			p.Runs = append(p.Runs, types.TextRun{Text: inline.Text, Style: style, Link: inline.Link})
+		}
+		paragraphs[i] = p
+	}
+	return paragraphs, nil
+}
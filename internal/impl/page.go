@@ -27,11 +27,29 @@ func (p *pageImpl) AddCell() types.PdfTemplatePageCell {
 	return cell
 }
 
+func (p *pageImpl) AddRepeat(expression string) types.PdfTemplatePageCell {
+	return p.AddCell().Repeat(expression)
+}
+
+func (p *pageImpl) AddImage(source string) types.PdfTemplatePageCell {
+	return p.AddCell().Attribute("image", source)
+}
+
 func (p *pageImpl) BookmarkTitle(bookmark string) types.PdfTemplatePage {
 	p.page.BookmarkTitle = bookmark
 	return p
 }
 
+func (p *pageImpl) PageSetup(orientation types.PageOrientation, pageSize types.PageSize) types.PdfTemplatePage {
+	if orientation != "" {
+		p.Attribute("orientation", string(orientation))
+	}
+	if pageSize != "" {
+		p.Attribute("size", string(pageSize))
+	}
+	return p
+}
+
 func (p *pageImpl) StyleList(name string, more ...string) types.PdfTemplatePage {
 	if p.page.StyleList == nil {
 		p.page.StyleList = make([]string, 0)
@@ -0,0 +1,689 @@
+package impl
+
+import (
+	"io"
+	"math"
+
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Holds the state of a single template render
+type renderer struct {
+	doc        *types.Document
+	pdfDoc     types.PdfDocument
+	scope      *expr.Scope
+	lenient    bool // expressions that fail to evaluate are drawn as written, for templates rendered without data
+	totalPages int
+
+	// template page and displayed page number of every physical page
+	pages   []*types.Page
+	numbers []int
+
+	// last physical page of every section, known after the layout pass
+	sectionEnds map[*types.Section]int
+
+	// bookmarked pages and cells in document order, and those of the layout pass drawn by the tables of contents
+	entries []tocEntry
+	toc     []tocEntry
+	tocs    int // number of tables of contents rendered
+	level   int // outline level of the last bookmark
+
+	// first error raised inside the header/footer callbacks, which can't return errors
+	err error
+}
+
+// State shared by the cells rendered on one page
+type cellContext struct {
+	page       *types.Page
+	pageNo     int            // physical page number, starting at 1
+	vars       map[string]any // page variables, updated when content flows onto a new page
+	isPageCell bool
+	beforeCell func() // called before every drawn cell, if set
+}
+
+// Returns the displayed number of a physical page, which sections can restart
+func (r *renderer) pageNumber(pageNo int) int {
+	if pageNo < 1 || pageNo > len(r.numbers) {
+		return pageNo
+	}
+	return r.numbers[pageNo-1]
+}
+
+// Returns the scope used to evaluate expressions on a page
+func (r *renderer) pageScope(pageNo int) *expr.Scope {
+	return r.scope.With(map[string]any{
+		"page":  r.pageNumber(pageNo),
+		"total": r.totalPages,
+	})
+}
+
+// Resolves the expressions of a text. `page` and `total` are bound by the scope.
+func (r *renderer) resolveText(text string, scope *expr.Scope) (string, error) {
+	if r.lenient {
+		return expr.InterpolateLenient(text, scope), nil
+	}
+	return expr.Interpolate(text, scope)
+}
+
+// Returns a copy of the cell with the expressions of its text, spans and link resolved, and its markdown text parsed
+func (r *renderer) resolveCell(cell *types.Cell, scope *expr.Scope) (*types.Cell, error) {
+	text, err := r.resolveText(cell.Text, scope)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *cell
+	resolved.Text = text
+	if resolved.Href, resolved.Anchor, err = r.resolveLink(cell, scope); err != nil {
+		return nil, err
+	}
+	if cell.ContentType == types.CT_MARKDOWN {
+		if resolved.RichText, err = r.markdownText(cell, text); err != nil {
+			return nil, errors.Wrap(err, "markdown")
+		}
+	}
+	if len(cell.Spans) > 0 {
+		resolved.Spans = make([]*types.Span, len(cell.Spans))
+		for i, span := range cell.Spans {
+			s := *span
+			if s.Text, err = r.resolveText(span.Text, scope); err != nil {
+				return nil, errors.Wrapf(err, "span %d", i)
+			}
+			if len(cell.ConditionalStyles) > 0 {
+				// the font of the cell may come from its conditional styles, which the span inherited before they applied
+				if err := r.restyleSpan(&s, cell); err != nil {
+					return nil, errors.Wrapf(err, "span %d", i)
+				}
+			}
+			resolved.Spans[i] = &s
+		}
+	}
+	return &resolved, nil
+}
+
+// Inherits the font of the styled cell again, under the attributes and styles of the span
+func (r *renderer) restyleSpan(span *types.Span, cell *types.Cell) error {
+	span.Element = span.Element.Clone()
+	span.Background = nil
+	inheritSpan(span, cell)
+	attrs, _, err := combineAttributes(r.doc, span.StyleList, span.Attrs)
+	if err != nil {
+		return err
+	}
+	return applyAttributes("span", attrs, span, cell, false)
+}
+
+// Returns the link target and anchor name of the cell with their expressions resolved
+func (r *renderer) resolveLink(cell *types.Cell, scope *expr.Scope) (href, anchor string, err error) {
+	if href, err = r.resolveText(cell.Href, scope); err != nil {
+		return "", "", errors.Wrap(err, "href")
+	}
+	if anchor, err = r.resolveText(cell.Anchor, scope); err != nil {
+		return "", "", errors.Wrap(err, "anchor")
+	}
+	return href, anchor, nil
+}
+
+// Evaluates a render condition. An empty condition always holds.
+func (r *renderer) test(condition string, scope *expr.Scope) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+	ok, err := expr.Test(condition, scope)
+	if err != nil {
+		return false, errors.Wrap(err, "condition")
+	}
+	return ok, nil
+}
+
+// Returns the cell with its active conditional styles applied
+func (r *renderer) styleCell(cell *types.Cell, scope *expr.Scope) (*types.Cell, error) {
+	var styled *types.Cell
+	for _, cs := range cell.ConditionalStyles {
+		ok, err := r.test(cs.Condition, scope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "style `%s`", cs.Style.Name)
+		}
+		if !ok {
+			continue
+		}
+		if styled == nil {
+			styled = cell.Clone()
+		}
+		if err := applyAttributes("cell", cs.Style.Attributes, styled, nil, false); err != nil {
+			return nil, errors.Wrapf(err, "style `%s`", cs.Style.Name)
+		}
+	}
+	if styled == nil {
+		return cell, nil
+	}
+	if styled.Image != cell.Image {
+		// image set by a conditional style
+		data, err := r.doc.ResolveImage(styled.Image)
+		if err != nil {
+			return nil, errors.Wrap(err, "image")
+		}
+		styled.ImageData = data
+	}
+	return styled, nil
+}
+
+func (r *renderer) renderCells(c types.Canvas, cells []*types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	for i, cell := range cells {
+		if err := r.renderCell(c, cell, ctx, scope); err != nil {
+			return errors.Wrapf(err, "cell %d", i)
+		}
+	}
+	return nil
+}
+
+// Returns one scope per element of the repeat expression, or the scope itself when there is no repeat
+func (r *renderer) repeatScopes(repeat string, scope *expr.Scope) ([]*expr.Scope, error) {
+	if repeat == "" {
+		return []*expr.Scope{scope}, nil
+	}
+	rep, err := expr.CompileRepeat(repeat)
+	if err != nil {
+		return nil, err
+	}
+	items, err := rep.Items(scope)
+	if err != nil {
+		return nil, err
+	}
+	scopes := make([]*expr.Scope, len(items))
+	for i, item := range items {
+		scopes[i] = rep.Scope(scope, i, item)
+	}
+	return scopes, nil
+}
+
+func (r *renderer) renderCell(c types.Canvas, cell *types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	scopes, err := r.repeatScopes(cell.Repeat, scope)
+	if err != nil {
+		return err
+	}
+	for i, s := range scopes {
+		if err := r.renderCellOnce(c, cell, ctx, s); err != nil {
+			if cell.Repeat != "" {
+				return errors.Wrapf(err, "item %d", i)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *renderer) renderCellOnce(c types.Canvas, cell *types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	visible, err := r.test(cell.Condition, scope)
+	if err != nil || !visible {
+		return err
+	}
+	if cell.Table != nil {
+		return r.renderTable(c, cell, ctx, scope)
+	}
+	if cell.Toc != nil {
+		return r.renderToc(c, cell, ctx)
+	}
+	if cell.IsContainer() {
+		return r.renderContainer(c, cell, ctx, scope)
+	}
+	if len(cell.Cells) > 0 {
+		// groups render their children in place
+		return r.renderCells(c, cell.Cells, ctx, scope)
+	}
+	styled, err := r.styleCell(cell, scope)
+	if err != nil {
+		return err
+	}
+	resolved, err := r.resolveCell(styled, scope)
+	if err != nil {
+		return err
+	}
+	if ctx.beforeCell != nil {
+		ctx.beforeCell()
+	}
+	if ctx.isPageCell && ctx.page.Flow && !resolved.Absolute {
+		_, h := resolved.GetSize(c, r.doc, nil)
+		margin, _ := resolved.GetSpacing(c, r.doc, nil)
+		if err := r.ensureSpace(c, ctx, margin.Top+h+margin.Bottom); err != nil {
+			return err
+		}
+	}
+	y := c.GetY()
+	if ctx.isPageCell && resolved.Absolute {
+		y = resolved.Top
+	}
+	margin, _ := resolved.GetSpacing(c, r.doc, nil)
+	if err := r.bookmarkCell(resolved, ctx, scope, y+margin.Top); err != nil {
+		return err
+	}
+	resolved.Render(c, 0, r.doc, ctx.page, ctx.isPageCell)
+	return nil
+}
+
+// Starts a new page when a box of the given height does not fit below the cursor
+func (r *renderer) ensureSpace(c types.Canvas, ctx *cellContext, h float64) error {
+	dc := c.GetDrawingRect()
+	y := c.GetY()
+	if y+h <= dc.Top+dc.Bottom || y <= dc.Top {
+		return nil
+	}
+	if err := r.endPage(c, ctx.page); err != nil {
+		return err
+	}
+	_, err := r.beginPage(ctx, false)
+	return err
+}
+
+// footer and header render
+func (r *renderer) renderSection(p types.PdfPage, pageIndex int, inFooter bool) {
+	if r.err != nil {
+		return
+	}
+
+	var section *types.Element
+	var area *types.SectionArea
+	var cells []*types.Cell
+	var name string
+	ctx := &cellContext{page: r.pages[pageIndex], pageNo: pageIndex + 1}
+	scope := r.pageScope(ctx.pageNo)
+
+	c := p.GetCanvas()
+
+	head, foot := r.sections(ctx.page)
+	if inFooter {
+		if ctx.page.NoFooter {
+			return
+		}
+		first, last := r.sectionBounds(ctx.pageNo, foot != &r.doc.Foot)
+		foot = foot.Select(ctx.page.FooterVariant, r.pageNumber(ctx.pageNo), first, last)
+		section = &foot.Element
+		area = &foot.SectionArea
+		cells = foot.Cells
+		name = "footer"
+	} else {
+		if ctx.page.NoHeader {
+			return
+		}
+		first, last := r.sectionBounds(ctx.pageNo, head != &r.doc.Head)
+		head = head.Select(ctx.page.HeaderVariant, r.pageNumber(ctx.pageNo), first, last)
+		section = &head.Element
+		area = &head.SectionArea
+		cells = head.Cells
+		name = "header"
+	}
+
+	visible, err := r.test(section.Condition, scope)
+	if err != nil {
+		r.err = errors.Wrapf(err, "%s of page %d", name, ctx.pageNo)
+		return
+	}
+	if !visible {
+		return
+	}
+
+	rc := c.GetDrawingRect()
+	pc := c.GetPageRect()
+	m := c.GetMargins()
+	margins, height, ok := area.GetExtent(m, pc.Right, pc.Bottom, inFooter, r.doc.DisplayUnit)
+
+	// the band spans the page margin when it has no height
+	band := types.Rect{Left: margins.Left, Right: pc.Right - margins.Left - margins.Right, Bottom: height}
+	switch {
+	case inFooter && ok:
+		band.Top = pc.Bottom - margins.Bottom - height
+	case inFooter:
+		band.Top, band.Bottom = rc.Top+rc.Bottom, m.Bottom
+	case ok:
+		band.Top = margins.Top
+	default:
+		band.Bottom = rc.Top
+	}
+
+	// the cells of the band are rendered in its padded box
+	padding := area.Padding.Resolve(band.Right, band.Bottom, types.Margins{}, r.doc.DisplayUnit)
+	box := padding.Inset(band)
+	defer c.SetMargins(m)
+	c.SetMargins(types.Margins{Left: box.Left, Top: box.Top, Right: pc.Right - box.Left - box.Right, Bottom: pc.Bottom - box.Top - box.Bottom})
+	c.SetXY(box.Left, box.Top)
+
+	// cells flow from the top of the band, or are centered in the top margin by headers without a height
+	if !inFooter && !ok {
+		ctx.beforeCell = func() {
+			x := c.GetX() // cache X because .SetX resets 'X' coordinate
+			c.SetY(box.Top + (box.Bottom * .5) - (c.GetTextHeight() * .5))
+			c.SetX(x)
+		}
+	}
+	if err := r.renderCells(c, cells, ctx, scope); err != nil {
+		r.err = errors.Wrapf(err, "%s of page %d", name, ctx.pageNo)
+	}
+}
+
+// Returns the header and footer of the template page, those of its section when set
+func (r *renderer) sections(page *types.Page) (*types.Header, *types.Footer) {
+	head, foot := &r.doc.Head, &r.doc.Foot
+	if section := page.Section; section != nil {
+		if section.Head != nil {
+			head = section.Head
+		}
+		if section.Foot != nil {
+			foot = section.Foot
+		}
+	}
+	return head, foot
+}
+
+// Returns whether the physical page is the first and the last page of its section, or of the document when
+// the section shares the document header or footer
+func (r *renderer) sectionBounds(pageNo int, inSection bool) (first, last bool) {
+	if !inSection {
+		return pageNo == 1, pageNo == r.totalPages
+	}
+	section := r.pages[pageNo-1].Section
+	first = pageNo == 1 || r.pages[pageNo-2].Section != section
+	return first, pageNo == r.sectionEnds[section]
+}
+
+// Returns the page setup of the template page. Pages override the size and orientation of their section.
+// The margins make room for the header and footer with a height, all pages of a section reserve the tallest
+// band of the header and footer variants.
+func (r *renderer) pageSetup(page *types.Page) *types.PageSetup {
+	setup := &types.PageSetup{NoAutoPageBreak: true} // page breaks are handled by the renderer
+	if section := page.Section; section != nil {
+		setup.Orientation = section.Orientation
+		setup.Size = section.PageSize
+	}
+	if page.Orientation != "" {
+		setup.Orientation = page.Orientation
+	}
+	if page.PageSize != "" {
+		setup.Size = page.PageSize
+	}
+	pageW, pageH := r.pdfDoc.GetPageSize(setup)
+	// margins of the page override those of its section, which override those of the document
+	margins := r.doc.Margins.Resolve(pageW, pageH, r.pdfDoc.GetMargins(), r.doc.DisplayUnit)
+	if section := page.Section; section != nil {
+		margins = section.Margins.Resolve(pageW, pageH, margins, r.doc.DisplayUnit)
+	}
+	margins = page.Margins.Resolve(pageW, pageH, margins, r.doc.DisplayUnit)
+	reserve := func(area *types.SectionArea, footer bool, edge *float64) {
+		if band, height, ok := area.GetExtent(margins, pageW, pageH, footer, r.doc.DisplayUnit); ok {
+			*edge = max(*edge, band.Top+height+band.Bottom)
+		}
+	}
+	head, foot := r.sections(page)
+	reserve(&head.SectionArea, false, &margins.Top)
+	for _, variant := range head.Variants {
+		reserve(&variant.SectionArea, false, &margins.Top)
+	}
+	reserve(&foot.SectionArea, true, &margins.Bottom)
+	for _, variant := range foot.Variants {
+		reserve(&variant.SectionArea, true, &margins.Bottom)
+	}
+	setup.Margins = &margins
+	return setup
+}
+
+func (r *renderer) bookmarkTitle(page *types.Page, pageNo int) (string, error) {
+	scope := r.pageScope(pageNo)
+	if page.BookmarkTitle != "" {
+		return r.resolveText(page.BookmarkTitle, scope)
+	}
+	if page.Section != nil && page.Section.PageBookmarkTemplate != "" {
+		return r.resolveText(page.Section.PageBookmarkTemplate, scope)
+	}
+	if r.doc.PageBookmarkTemplate != "" {
+		return r.resolveText(r.doc.PageBookmarkTemplate, scope)
+	}
+	return r.resolveText("Page ${pad(page, 4)}", scope)
+}
+
+// Adds a physical page for the template page. Page bookmarks are only set on the first physical page, and section
+// bookmarks on the first page of their section. Sections restart the page numbering on their first page when they
+// set a page number.
+func (r *renderer) beginPage(ctx *cellContext, first bool) (types.Canvas, error) {
+	number := 1
+	if n := len(r.numbers); n > 0 {
+		number = r.numbers[n-1] + 1
+	}
+	section := ctx.page.Section
+	sectionStart := section != nil && (len(r.pages) == 0 || r.pages[len(r.pages)-1].Section != section)
+	if sectionStart && section.PageNumber > 0 {
+		number = section.PageNumber
+	}
+	r.pages = append(r.pages, ctx.page)
+	r.numbers = append(r.numbers, number)
+	ctx.pageNo = len(r.pages)
+	ctx.vars["page"] = number
+
+	pdfPage := r.pdfDoc.AddNewPage(r.pageSetup(ctx.page), r.renderSection)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	c := pdfPage.GetCanvas()
+	dc := c.GetDrawingRect()
+
+	if sectionStart && section.BookmarkTitle != "" {
+		title, err := r.resolveText(section.BookmarkTitle, r.pageScope(ctx.pageNo))
+		if err != nil {
+			return nil, errors.Wrap(err, "section bookmark")
+		}
+		anchor, err := r.bookmarkTo(&section.Element, r.pageScope(ctx.pageNo))
+		if err != nil {
+			return nil, errors.Wrap(err, "section bookmark")
+		}
+		r.bookmark(title, anchor, sectionLevel(section), ctx, 0)
+	}
+	if first && r.doc.PageBookmarks {
+		title, err := r.bookmarkTitle(ctx.page, ctx.pageNo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bookmark of page %d", ctx.page.PageIndex)
+		}
+		anchor, err := r.bookmarkTo(&ctx.page.Element, r.pageScope(ctx.pageNo))
+		if err != nil {
+			return nil, errors.Wrapf(err, "bookmark of page %d", ctx.page.PageIndex)
+		}
+		r.bookmark(title, anchor, pageLevel(ctx.page), ctx, 0)
+	}
+	if first && ctx.page.Anchor != "" {
+		anchor, err := r.resolveText(ctx.page.Anchor, r.pageScope(ctx.pageNo))
+		if err != nil {
+			return nil, errors.Wrapf(err, "anchor of page %d", ctx.page.PageIndex)
+		}
+		c.SetAnchor(anchor, 0)
+	}
+
+	// background
+	if r.doc.Background != nil {
+		c.DrawRect(*dc, r.doc.Background)
+	}
+	if r.doc.Watermark.Layer != types.WL_ABOVE {
+		if err := r.drawWatermark(c, ctx.page); err != nil {
+			return nil, err
+		}
+	}
+
+	// cells are laid out inside the padding of the page
+	if padding := r.pagePadding(c, ctx.page); padding != (types.Margins{}) {
+		m := c.GetMargins()
+		m.Left, m.Top, m.Right, m.Bottom = m.Left+padding.Left, m.Top+padding.Top, m.Right+padding.Right, m.Bottom+padding.Bottom
+		c.SetMargins(m)
+		c.SetXY(m.Left, m.Top)
+	}
+	return c, nil
+}
+
+// Returns the padding of the page. Percentages resolve against the page size.
+func (r *renderer) pagePadding(c types.Canvas, page *types.Page) types.Margins {
+	pc := c.GetPageRect()
+	return page.Padding.Resolve(pc.Right, pc.Bottom, types.Margins{}, r.doc.DisplayUnit)
+}
+
+func (r *renderer) endPage(c types.Canvas, page *types.Page) error {
+	// restore the page margins for the border, watermark and footer
+	if padding := r.pagePadding(c, page); padding != (types.Margins{}) {
+		m := c.GetMargins()
+		m.Left, m.Top, m.Right, m.Bottom = m.Left-padding.Left, m.Top-padding.Top, m.Right-padding.Right, m.Bottom-padding.Bottom
+		c.SetMargins(m)
+	}
+	dc := c.GetDrawingRect()
+
+	// draw border
+	page.DrawBorder(c, dc.Left, dc.Top, dc.Right+dc.Left, dc.Bottom+dc.Top)
+
+	if r.doc.Watermark.Layer == types.WL_ABOVE {
+		return r.drawWatermark(c, page)
+	}
+	return nil
+}
+
+// Draws the document watermark on the current page, unless the page opts out.
+// The text is rotated around its center, and scaled to the page diagonal when the watermark fits.
+func (r *renderer) drawWatermark(c types.Canvas, page *types.Page) error {
+	wm := &r.doc.Watermark
+	if (wm.Text == "" && wm.ImageData == nil) || page.NoWatermark {
+		return nil
+	}
+	pageNo := len(r.pages)
+	scope := r.pageScope(pageNo)
+	visible, err := r.test(wm.Condition, scope)
+	if err != nil {
+		return errors.Wrap(err, "watermark")
+	}
+	if !visible {
+		return nil
+	}
+
+	x, y := c.GetXY()
+	defer c.SetXY(x, y)
+	dc := c.GetDrawingRect()
+	if wm.ImageData != nil {
+		w, h := wm.GetImageSize(c, r.doc.DisplayUnit)
+		cx, cy := wm.Center(*dc, w, h)
+		c.BeginTransform()
+		c.Rotate(wm.Angle, cx, cy)
+		c.DrawImage(types.Rect{Left: cx - w/2, Top: cy - h/2, Right: w, Bottom: h}, wm.ImageData, &wm.ImageStyle)
+		c.EndTransform()
+	}
+	if wm.Text == "" {
+		return nil
+	}
+	text, err := r.resolveText(wm.Text, scope)
+	if err != nil {
+		return errors.Wrap(err, "watermark")
+	}
+
+	brush := wm.TextStyle
+	brush.Alignment = "CM"
+	brush.DisplayStyle = types.DISPLAY_COLUMN
+	w, h := c.MeasureText(text, &brush)
+	pc := c.GetPageRect()
+	if wm.GetFit() && w > 0 {
+		// leave a margin of one line at both ends of the diagonal
+		diagonal := math.Hypot(pc.Right, pc.Bottom)
+		scale := diagonal / (w + 2*h)
+		brush.FontSize.Value *= scale
+		w, h = w*scale, h*scale
+	}
+
+	cx, cy := wm.Center(*dc, w, h)
+	c.BeginTransform()
+	c.Rotate(wm.Angle, cx, cy)
+	// the text is drawn from the center as the cursor does not take negative positions
+	c.Translate(-w/2, -h/2)
+	c.SetXY(cx, cy)
+	c.DrawText(w, h, text, &brush)
+	c.EndTransform()
+	return nil
+}
+
+func (r *renderer) renderPages(pages []*types.Page) error {
+	for _, page := range pages {
+		ctx := &cellContext{page: page, isPageCell: true, vars: map[string]any{"total": r.totalPages}}
+		c, err := r.beginPage(ctx, true)
+		if err != nil {
+			return err
+		}
+
+		// page cells
+		if err := r.renderCells(c, page.Cells, ctx, r.scope.With(ctx.vars)); err != nil {
+			return errors.Wrapf(err, "page %d", page.PageIndex)
+		}
+
+		if err := r.endPage(c, page); err != nil {
+			return err
+		}
+	}
+	return r.outline()
+}
+
+func (r *renderer) render(w io.Writer) error {
+	// set document title, bookmarks, etc
+	r.pdfDoc.SetTitle(r.doc.Title)
+
+	// setup fonts
+	if err := r.pdfDoc.InitializeFonts(&r.doc.Fonts); err != nil {
+		return err
+	}
+
+	// page conditions are evaluated against the data only
+	pages := []*types.Page{}
+	for _, page := range r.doc.GetPages() {
+		visible, err := r.test(page.Condition, r.scope)
+		if err != nil {
+			return errors.Wrapf(err, "page %d", page.PageIndex)
+		}
+		if visible {
+			pages = append(pages, page)
+		}
+	}
+	if len(pages) == 0 {
+		return errors.New("no visible pages to render")
+	}
+
+	// layout pass: render into a scratch document to find the number of physical pages
+	layoutDoc, err := r.pdfDoc.NewLayoutDocument()
+	if err != nil {
+		return err
+	}
+	if err := layoutDoc.InitializeFonts(&r.doc.Fonts); err != nil {
+		return err
+	}
+	layout := &renderer{doc: r.doc, pdfDoc: layoutDoc, scope: r.scope, lenient: r.lenient, totalPages: len(pages)}
+	if err := layout.renderPages(pages); err != nil {
+		return err
+	}
+	if layout.tocs > 0 {
+		// lay out again with the entries of the tables of contents, which can move the pages that follow them
+		if layoutDoc, err = r.pdfDoc.NewLayoutDocument(); err != nil {
+			return err
+		}
+		if err := layoutDoc.InitializeFonts(&r.doc.Fonts); err != nil {
+			return err
+		}
+		layout = &renderer{doc: r.doc, pdfDoc: layoutDoc, scope: r.scope, lenient: r.lenient, totalPages: len(layout.pages), toc: layout.entries}
+		if err := layout.renderPages(pages); err != nil {
+			return err
+		}
+	}
+	r.toc = layout.entries
+
+	r.totalPages = len(layout.pages)
+	r.sectionEnds = map[*types.Section]int{}
+	for i, page := range layout.pages {
+		r.sectionEnds[page.Section] = i + 1
+	}
+	if err := r.renderPages(pages); err != nil {
+		return err
+	}
+
+	if err := r.pdfDoc.Save(w); err != nil {
+		return err
+	}
+
+	// the last footer is rendered while saving
+	return r.err
+}
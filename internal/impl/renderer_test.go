@@ -0,0 +1,1071 @@
+package impl_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/impl"
+	"github.com/gintec-rdl/pdf-go/internal/pdf"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Document recording what the renderer draws on each physical page, starting at 1
+type recorder struct {
+	types.PdfDocument
+	page      int      // page drawn on, following the page changes of the document
+	sizes     []string // `WxH` size of every page, in millimeters
+	texts     map[int][]string
+	links     map[int][]string
+	images    map[int][]types.Rect // rects of the images, their width in Right and height in Bottom
+	anchors   map[string]int
+	styles    map[string]string     // font style of every text, its flags sorted, `B` for bold
+	boxes     map[string]types.Rect // box of every text, its width in Right and height in Bottom
+	bookmarks []string              // `level title @page`
+	marks     []float64             // position y of every bookmark
+}
+
+// Canvas recording into the recorder
+type recordingCanvas struct {
+	types.Canvas
+	rec *recorder
+}
+
+type recordingPage struct {
+	types.PdfPage
+	rec *recorder
+}
+
+func (p *recordingPage) GetCanvas() types.Canvas {
+	return &recordingCanvas{Canvas: p.PdfPage.GetCanvas(), rec: p.rec}
+}
+
+func (r *recorder) AddNewPage(setup *types.PageSetup, fn func(p types.PdfPage, pageIndex int, inFooter bool)) types.PdfPage {
+	var wrapped func(p types.PdfPage, pageIndex int, inFooter bool)
+	if fn != nil {
+		wrapped = func(p types.PdfPage, pageIndex int, inFooter bool) {
+			page := r.page
+			r.page = pageIndex + 1
+			fn(&recordingPage{PdfPage: p, rec: r}, pageIndex, inFooter)
+			r.page = page
+		}
+	}
+	w, h := r.GetPageSize(setup)
+	r.sizes = append(r.sizes, fmt.Sprintf("%.0fx%.0f", w, h))
+	p := r.PdfDocument.AddNewPage(setup, wrapped)
+	r.page = r.GetPageCount()
+	return &recordingPage{PdfPage: p, rec: r}
+}
+
+func (r *recorder) GetPage(page int) (types.PdfPage, bool) {
+	p, ok := r.PdfDocument.GetPage(page)
+	if !ok {
+		return nil, false
+	}
+	r.page = page
+	return &recordingPage{PdfPage: p, rec: r}, true
+}
+
+func (r *recorder) SetBookmark(title string, level int, y float64) {
+	r.bookmarks = append(r.bookmarks, fmt.Sprintf("%d %s @%d", level, title, r.page))
+	r.marks = append(r.marks, y)
+	r.PdfDocument.SetBookmark(title, level, y)
+}
+
+func (c *recordingCanvas) DrawText(w, h float64, text string, brush *types.TextBrush) {
+	if text != "" {
+		c.rec.texts[c.rec.page] = append(c.rec.texts[c.rec.page], text)
+		c.rec.styles[text] = fontStyle(brush.FontStyle)
+		c.rec.boxes[text] = c.box(w, h)
+	}
+	c.Canvas.DrawText(w, h, text, brush)
+}
+
+func (c *recordingCanvas) DrawRichText(w, h float64, paragraphs []types.TextParagraph, brush *types.TextBrush) {
+	lines := make([]string, len(paragraphs))
+	for i, p := range paragraphs {
+		for _, run := range p.Runs {
+			lines[i] += run.Text
+			if run.Link != "" {
+				c.rec.links[c.rec.page] = append(c.rec.links[c.rec.page], run.Link)
+			}
+			if run.Style != nil {
+				c.rec.styles[run.Text] = fontStyle(run.Style.FontStyle)
+			} else {
+				c.rec.styles[run.Text] = fontStyle(brush.FontStyle)
+			}
+		}
+	}
+	text := strings.Join(lines, "\n")
+	c.rec.texts[c.rec.page] = append(c.rec.texts[c.rec.page], text)
+	c.rec.boxes[text] = c.box(w, h)
+	c.Canvas.DrawRichText(w, h, paragraphs, brush)
+}
+
+// Returns the flags of the font style in a stable order
+func fontStyle(fs types.FontStyle) string {
+	flags := []rune(fs.String())
+	slices.Sort(flags)
+	return string(flags)
+}
+
+// Returns the box of size w, h at the cursor
+func (c *recordingCanvas) box(w, h float64) types.Rect {
+	x, y := c.GetXY()
+	return types.Rect{Left: x, Top: y, Right: w, Bottom: h}
+}
+
+func (c *recordingCanvas) DrawImage(rect types.Rect, img *types.ImageData, brush *types.ImageBrush) {
+	c.rec.images[c.rec.page] = append(c.rec.images[c.rec.page], rect)
+	c.Canvas.DrawImage(rect, img, brush)
+}
+
+func (c *recordingCanvas) Link(rect types.Rect, target string) {
+	c.rec.links[c.rec.page] = append(c.rec.links[c.rec.page], target)
+	c.Canvas.Link(rect, target)
+}
+
+func (c *recordingCanvas) LinkPage(rect types.Rect, page int, y float64) {
+	c.rec.links[c.rec.page] = append(c.rec.links[c.rec.page], fmt.Sprintf("page %d", page))
+	c.Canvas.LinkPage(rect, page, y)
+}
+
+func (c *recordingCanvas) SetAnchor(name string, y float64) {
+	if _, ok := c.rec.anchors[name]; !ok {
+		c.rec.anchors[name] = c.rec.page
+	}
+	c.Canvas.SetAnchor(name, y)
+}
+
+// Returns the texts drawn on every page
+func (r *recorder) pages() [][]string {
+	pages := make([][]string, r.GetPageCount())
+	for i := range pages {
+		pages[i] = r.texts[i+1]
+	}
+	return pages
+}
+
+// Renders the template against the data into a temporary file, recording what is drawn
+func render(t *testing.T, b types.PdfTemplateBuilder, data any) (*recorder, error) {
+	tpl, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	rec := newRecorder(t, tpl)
+	return rec, tpl.RenderDataF(rec, data, filepath.Join(t.TempDir(), "out.pdf"))
+}
+
+// Returns a recorder on a new document for the template
+func newRecorder(t *testing.T, tpl types.PdfTemplate) *recorder {
+	doc, err := pdf.NewPdfDocument(tpl.GetOrientation(), tpl.GetPageSize(), tpl.GetUnit())
+	assert.Nil(t, err)
+	return &recorder{PdfDocument: doc, texts: map[int][]string{}, links: map[int][]string{}, images: map[int][]types.Rect{}, anchors: map[string]int{}, styles: map[string]string{}, boxes: map[string]types.Rect{}}
+}
+
+func newBuilder() types.PdfTemplateBuilder {
+	return impl.NewTemplateBuilder(types.PO_PORTRAIT, types.PAGE_SIZE_A4, types.DU_MILIMETER)
+}
+
+func TestPageVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"page and total", "Page ${page} of ${total}", []string{"Page 1 of 2", "Page 2 of 2"}},
+		{"expressions", "${page + 0}/${total * 1}", []string{"1/2", "2/2"}},
+		{"escape", "$${page} ${page}", []string{"${page} 1", "${page} 2"}},
+		{"padding", "${pad(page, 4)}", []string{"0001", "0002"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.AddPage().AddCell().Text(tt.text)
+			b.AddPage().AddCell().Text(tt.text)
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, [][]string{{tt.want[0]}, {tt.want[1]}}, rec.pages())
+		})
+	}
+}
+
+func TestDataBinding(t *testing.T) {
+	data := map[string]any{"customer": map[string]any{"name": "ACME"}}
+
+	b := newBuilder()
+	b.ShowBookmarks(true)
+	b.AddPage().BookmarkTitle("${customer.name} ${page}").AddCell().Text("Bill to ${customer.name}")
+	rec, err := render(t, b, data)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"Bill to ACME"}}, rec.pages())
+	assert.Equal(t, []string{"0 ACME 1 @1"}, rec.bookmarks)
+
+	b = newBuilder()
+	b.AddPage().AddCell().Text("${customer.nmae}")
+	_, err = render(t, b, data)
+	assert.EqualError(t, err, "page 0: cell 0: `customer.nmae`: key `nmae` not found")
+}
+
+func TestRenderWithoutData(t *testing.T) {
+	b := newBuilder().ShowBookmarks(true)
+	b.AddPage().AddCell().Text("Bill to ${customer.name}, page ${page} of ${total")
+	tpl, err := b.Build()
+	assert.Nil(t, err)
+	rec := newRecorder(t, tpl)
+	assert.Nil(t, tpl.RenderF(rec, filepath.Join(t.TempDir(), "out.pdf")))
+	assert.Equal(t, [][]string{{"Bill to ${customer.name}, page 1 of ${total"}}, rec.pages())
+	assert.Equal(t, []string{"0 Page 0001 @1"}, rec.bookmarks)
+}
+
+func TestRepeat(t *testing.T) {
+	data := map[string]any{
+		"lines": []map[string]any{{"name": "Widgets", "qty": 2}, {"name": "Gadgets", "qty": 1}},
+		"none":  []any{},
+		"count": 3,
+	}
+	tests := []struct {
+		name  string
+		build func(p types.PdfTemplatePage)
+		want  []string
+		err   string
+	}{
+		{
+			name:  "items",
+			build: func(p types.PdfTemplatePage) { p.AddCell().Text("${index}: ${item.name}").Repeat("lines") },
+			want:  []string{"0: Widgets", "1: Gadgets"},
+		},
+		{
+			name:  "named item and index",
+			build: func(p types.PdfTemplatePage) { p.AddCell().Text("${i + 1}. ${line.name}").Repeat("line, i in lines") },
+			want:  []string{"1. Widgets", "2. Gadgets"},
+		},
+		{
+			name: "group",
+			build: func(p types.PdfTemplatePage) {
+				p.AddRepeat("line in lines").
+					AddCell().Text("${line.name}").Parent().
+					AddCell().Text("x${line.qty}")
+			},
+			want: []string{"Widgets", "x2", "Gadgets", "x1"},
+		},
+		{
+			name:  "empty",
+			build: func(p types.PdfTemplatePage) { p.AddCell().Text("${item}").Repeat("none") },
+		},
+		{
+			name:  "missing",
+			build: func(p types.PdfTemplatePage) { p.AddCell().Text("${item}").Repeat("missing") },
+			err:   "`missing`: not defined",
+		},
+		{
+			name:  "not an array",
+			build: func(p types.PdfTemplatePage) { p.AddCell().Text("${item}").Repeat("count") },
+			err:   "`count`: cannot repeat over number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b.AddPage())
+			rec, err := render(t, b, data)
+			if tt.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, fmt.Sprint(err), tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, [][]string{tt.want}, rec.pages())
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	data := map[string]any{"discount": 0, "overdue": true}
+	tests := []struct {
+		name   string
+		build  func(b types.PdfTemplateBuilder)
+		want   [][]string
+		styles map[string]string
+		err    string
+	}{
+		{
+			name: "cells",
+			build: func(b types.PdfTemplateBuilder) {
+				p := b.AddPage()
+				p.AddCell().Text("Total")
+				p.AddCell().Text("Discount").Attribute("if", "discount > 0")
+				p.AddCell().Text("Overdue").Attribute("visible", "overdue")
+				p.AddCell().Text("Paid").Attribute("if", "invoice.paid")
+			},
+			want: [][]string{{"Total", "Overdue"}},
+		},
+		{
+			name: "pages and headers",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Header().Attribute("if", "page > 1").AddCell().Text("Continued")
+				b.AddPage().AddCell().Text("One")
+				b.AddPage().Attribute("if", "discount").AddCell().Text("Discounts")
+				b.AddPage().AddCell().Text("Two")
+			},
+			want: [][]string{{"One"}, {"Continued", "Two"}},
+		},
+		{
+			name: "conditional styles",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Style("strong", types.PdfTemplateAttributes{"font-style": "bold"})
+				p := b.AddPage()
+				p.AddCell().Text("Late").StyleList("strong if overdue")
+				p.AddCell().Text("Reduced").StyleList("strong if discount")
+			},
+			want:   [][]string{{"Late", "Reduced"}},
+			styles: map[string]string{"Late": "B", "Reduced": ""},
+		},
+		{
+			name:  "invalid condition",
+			build: func(b types.PdfTemplateBuilder) { b.AddPage().AddCell().Text("Total").Attribute("if", "discount >") },
+			err:   "attribute `if`: invalid condition",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b)
+			rec, err := render(t, b, data)
+			if tt.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, fmt.Sprint(err), tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.pages())
+			for text, style := range tt.styles {
+				assert.Equal(t, style, rec.styles[text], text)
+			}
+		})
+	}
+}
+
+func TestSpans(t *testing.T) {
+	data := map[string]any{"amount": "$1,200", "overdue": true, "discount": 0}
+	tests := []struct {
+		name   string
+		build  func(b types.PdfTemplateBuilder)
+		want   [][]string
+		styles map[string]string
+		err    string
+	}{
+		{
+			name: "fonts",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddCell().Attribute("font-style", "underline").
+					Span("Total: ", nil).
+					Span("${amount}", nil, "amount").
+					Span(", due now", types.PdfTemplateAttributes{"font-style": "italic"})
+			},
+			want:   [][]string{{"Total: $1,200, due now"}},
+			styles: map[string]string{"Total: ": "U", "$1,200": "BU", ", due now": "IU"},
+		},
+		{
+			name: "conditional styles",
+			build: func(b types.PdfTemplateBuilder) {
+				p := b.AddPage()
+				p.AddCell().StyleList("amount if overdue").Span("Late ", nil).Span("fee", types.PdfTemplateAttributes{"font-style": "italic"}).Attribute("display", "row")
+				p.AddCell().StyleList("amount if discount").Span("Reduced ", nil).Span("price", types.PdfTemplateAttributes{"font-style": "italic"})
+			},
+			want:   [][]string{{"Late fee", "Reduced price"}},
+			styles: map[string]string{"Late ": "B", "fee": "BI", "Reduced ": "", "price": "I"},
+		},
+		{
+			name: "conditional styles in tables",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddTable().Columns("auto").AddRow().AddCell().StyleList("amount if overdue").Span("Late ", nil).Span("fee", nil, "amount")
+			},
+			want:   [][]string{{"Late fee"}},
+			styles: map[string]string{"Late ": "B", "fee": "B"},
+		},
+		{
+			name: "invalid attribute",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddCell().Span("Total", types.PdfTemplateAttributes{"font-size": "big"})
+			},
+			err: "error in cell 0 of page 0: span 0: attribute `font-size`: invalid unit big",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.Style("amount", types.PdfTemplateAttributes{"font-style": "bold", "font-size": "150%"})
+			tt.build(b)
+			rec, err := render(t, b, data)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.pages())
+			for text, style := range tt.styles {
+				assert.Equal(t, style, rec.styles[text], "style of `%s`", text)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	text := "## Terms\n\n" +
+		"Due within **30 days**, *late* fees apply, see [our portal](https://example.com/terms).\n\n" +
+		"- Claims within `7 days`\n" +
+		"  - in writing\n\n" +
+		"1. Return a copy to ${company}"
+	tests := []struct {
+		name   string
+		styles types.PdfTemplateAttributes // attributes of the `md-strong` document style
+		want   map[string]string
+		err    string
+	}{
+		{
+			name: "default styles",
+			want: map[string]string{"Terms": "B", "Due within ": "", "30 days": "B", "late": "I", "our portal": "U", "7 days": ""},
+		},
+		{
+			name:   "document styles",
+			styles: types.PdfTemplateAttributes{"font-style": "underline"},
+			want:   map[string]string{"Terms": "B", "30 days": "BU", "late": "I"},
+		},
+		{
+			name:   "invalid document style",
+			styles: types.PdfTemplateAttributes{"font-size": "big"},
+			err:    "page 0: cell 0: markdown: style `md-strong`: attribute `font-size`: invalid unit big",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			if tt.styles != nil {
+				b.Style("md-strong", tt.styles)
+			}
+			b.AddPage().AddCell().Text(text).Attributes(types.PdfTemplateAttributes{"content-type": "markdown", "wrap": "true", "width": "90mm"})
+			rec, err := render(t, b, map[string]any{"company": "Acme Ltd."})
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, [][]string{{"Terms\n" +
+				"Due within 30 days, late fees apply, see our portal.\n" +
+				"Claims within 7 days\n" +
+				"in writing\n" +
+				"Return a copy to Acme Ltd."}}, rec.pages())
+			assert.Equal(t, []string{"https://example.com/terms"}, rec.links[1])
+			for text, style := range tt.want {
+				assert.Equal(t, style, rec.styles[text], "style of `%s`", text)
+			}
+		})
+	}
+}
+
+func TestMarkdownLinkStyle(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		link string
+		want string
+	}{
+		{"bold", "See **[the terms](https://example.com/terms)**.", "the terms", "BU"},
+		{"italic", "See *[the terms](https://example.com/terms)*.", "the terms", "IU"},
+		{"heading", "## [Terms](https://example.com/terms)", "Terms", "BU"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.AddPage().AddCell().Text(tt.text).Attributes(types.PdfTemplateAttributes{"content-type": "markdown", "wrap": "true", "width": "90mm"})
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.styles[tt.link], "style of `%s`", tt.link)
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	customers := map[string]any{"customers": []string{"ACME", "Globex", "Initech"}}
+	tests := []struct {
+		name      string
+		build     func(b types.PdfTemplateBuilder)
+		links     map[int][]string
+		anchors   map[string]int
+		bookmarks []string
+		err       string
+	}{
+		{
+			name: "urls",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Footer().AddCell().Text("Portal").Attribute("href", "https://example.com/portal")
+				b.AddPage().AddCell().Text("Terms, see [our site](https://example.com)").Attribute("content-type", "markdown")
+			},
+			links: map[int][]string{1: {"https://example.com", "https://example.com/portal"}},
+		},
+		{
+			name: "anchors further down",
+			build: func(b types.PdfTemplateBuilder) {
+				index := b.AddPage()
+				index.AddCell().Text("See the [details](#details)").Attributes(types.PdfTemplateAttributes{"content-type": "markdown", "display": "row"})
+				index.AddRepeat("customers").AddCell().Text("${item}").Attributes(types.PdfTemplateAttributes{"link-to": "customer-${index}", "display": "row"})
+				details := b.AddPage().Attributes(types.PdfTemplateAttributes{"anchor": "details", "flow": "true"})
+				details.AddRepeat("customers").AddCell().Text("${item}").Attributes(types.PdfTemplateAttributes{"anchor": "customer-${index}", "height": "100mm", "display": "row"})
+			},
+			links:   map[int][]string{1: {"#details", "#customer-0", "#customer-1", "#customer-2"}},
+			anchors: map[string]int{"details": 2, "customer-0": 2, "customer-1": 2, "customer-2": 3},
+		},
+		{
+			name: "bookmarks to anchors",
+			build: func(b types.PdfTemplateBuilder) {
+				b.ShowBookmarks(true)
+				b.AddPage().BookmarkTitle("Index").AddRepeat("customers").AddCell().Text("${item}").
+					Attributes(types.PdfTemplateAttributes{"bookmark": "${item}", "bookmark-to": "customer-${index}", "display": "row"})
+				details := b.AddPage().BookmarkTitle("Details").Attributes(types.PdfTemplateAttributes{"bookmark-to": "customer-2", "flow": "true"})
+				details.AddRepeat("customers").AddCell().Text("${item}").Attributes(types.PdfTemplateAttributes{"anchor": "customer-${index}", "height": "100mm", "display": "row"})
+			},
+			anchors:   map[string]int{"customer-0": 2, "customer-1": 2, "customer-2": 3},
+			bookmarks: []string{"0 Index @1", "1 ACME @2", "1 Globex @2", "1 Initech @3", "0 Details @3"},
+		},
+		{
+			name:  "undefined anchor",
+			build: func(b types.PdfTemplateBuilder) { b.AddPage().AddCell().Text("Go").Attribute("link-to", "nowhere") },
+			err:   "anchor `nowhere` is not defined",
+		},
+		{
+			name: "undefined bookmark anchor",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddCell().Text("Go").Attributes(types.PdfTemplateAttributes{"bookmark": "Go", "bookmark-to": "nowhere"})
+			},
+			err: "bookmark `Go`: anchor `nowhere` is not defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b)
+			rec, err := render(t, b, customers)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			if tt.links != nil {
+				assert.Equal(t, tt.links, rec.links)
+			}
+			if tt.anchors != nil {
+				assert.Equal(t, tt.anchors, rec.anchors)
+			}
+			assert.Equal(t, tt.bookmarks, rec.bookmarks)
+		})
+	}
+}
+
+func TestPageFlow(t *testing.T) {
+	lines := make([]int, 150)
+	for i := range lines {
+		lines[i] = i + 1
+	}
+	tests := []struct {
+		flow string
+		want [][]string // header, first and last line of every page
+	}{
+		{"true", [][]string{{"Page 1 of 3", "Line 1", "Line 63"}, {"Page 2 of 3", "Line 64", "Line 126"}, {"Page 3 of 3", "Line 127", "Line 150"}}},
+		{"false", [][]string{{"Page 1 of 1", "Line 1", "Line 150"}}},
+	}
+	for _, tt := range tests {
+		t.Run("flow "+tt.flow, func(t *testing.T) {
+			b := newBuilder().ShowBookmarks(true)
+			b.Header().AddCell().Text("Page ${page} of ${total}")
+			b.AddPage().Attribute("flow", tt.flow).BookmarkTitle("Lines").
+				AddCell().Text("Line ${item}").Attributes(types.PdfTemplateAttributes{"display": "row"}).Repeat("lines")
+			rec, err := render(t, b, map[string]any{"lines": lines})
+			assert.Nil(t, err)
+
+			got := [][]string{}
+			for _, page := range rec.pages() {
+				got = append(got, []string{page[0], page[1], page[len(page)-1]})
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []string{"0 Lines @1"}, rec.bookmarks)
+		})
+	}
+}
+
+// Returns a PNG image of the given size in pixels
+func testImage(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(255 * x / w), G: 80, B: uint8(255 * y / h), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImages(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	assert.Nil(t, os.WriteFile(logo, testImage(t, 200, 100), 0o644))
+	photo := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testImage(t, 100, 200))
+
+	tests := []struct {
+		name   string
+		source string
+		attrs  types.PdfTemplateAttributes
+		want   types.Rect
+		err    string
+	}{
+		{name: "natural size", source: "logo", want: types.Rect{Left: 10, Top: 10, Right: 70.56, Bottom: 35.28}},
+		{name: "width", source: "file://" + logo, attrs: types.PdfTemplateAttributes{"width": "50mm"}, want: types.Rect{Left: 10, Top: 10, Right: 50, Bottom: 25}},
+		{name: "height", source: photo, attrs: types.PdfTemplateAttributes{"height": "50mm"}, want: types.Rect{Left: 10, Top: 10, Right: 25, Bottom: 50}},
+		{
+			name:   "padding",
+			source: photo,
+			attrs:  types.PdfTemplateAttributes{"width": "40mm", "height": "40mm", "padding": "5mm"},
+			want:   types.Rect{Left: 15, Top: 15, Right: 30, Bottom: 30},
+		},
+		{name: "missing", source: "file://" + filepath.Join(t.TempDir(), "missing.png"), err: "image: failed to stat image file"},
+		{name: "unknown name", source: "banner", err: "image: expected a `file://` path, base64 data or the name of a document image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder().AddImage("logo", "file://"+logo)
+			b.AddPage().AddImage(tt.source).Attributes(tt.attrs)
+			rec, err := render(t, b, nil)
+			if tt.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, fmt.Sprint(err), tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			if assert.Equal(t, 1, len(rec.images[1])) {
+				got := rec.images[1][0]
+				assert.InDelta(t, tt.want.Left, got.Left, .01)
+				assert.InDelta(t, tt.want.Top, got.Top, .01)
+				assert.InDelta(t, tt.want.Right, got.Right, .01)
+				assert.InDelta(t, tt.want.Bottom, got.Bottom, .01)
+			}
+		})
+	}
+}
+
+func TestWatermark(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b types.PdfTemplateBuilder)
+		want  [][]string
+	}{
+		{
+			name: "every page",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Watermark("DRAFT ${page}")
+			},
+			want: [][]string{{"DRAFT 1", "One"}, {"DRAFT 2", "Two"}},
+		},
+		{
+			name: "page opting out",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Watermark("DRAFT")
+				b.AddPage().Attribute("watermark", "false").AddCell().Text("Three")
+			},
+			want: [][]string{{"DRAFT", "One"}, {"DRAFT", "Two"}, {"Three"}},
+		},
+		{
+			name: "layer",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Watermark("DRAFT").Attribute("layer", "above")
+			},
+			want: [][]string{{"One", "DRAFT"}, {"Two", "DRAFT"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.AddPage().AddCell().Text("One")
+			b.AddPage().AddCell().Text("Two")
+			tt.build(b)
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.pages())
+		})
+	}
+}
+
+func TestImageWatermark(t *testing.T) {
+	stamp := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testImage(t, 300, 120))
+	tests := []struct {
+		name      string
+		condition string
+		data      any
+		pages     []int // number of images on every page
+		err       string
+	}{
+		{name: "always", pages: []int{1, 1}},
+		{name: "first page when paid", condition: "paid && page == 1", data: map[string]any{"paid": true}, pages: []int{1, 0}},
+		{name: "not paid", condition: "paid && page == 1", data: map[string]any{"paid": false}, pages: []int{0, 0}},
+		{name: "invalid condition", condition: "paid == ", err: "invalid condition"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			wm := b.WatermarkImage(stamp).Size("60mm", "").Placement("RT", 20)
+			if tt.condition != "" {
+				wm.If(tt.condition)
+			}
+			b.AddPage().AddCell().Text("One")
+			b.AddPage().AddCell().Text("Two")
+			rec, err := render(t, b, tt.data)
+			if tt.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, fmt.Sprint(err), tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			for i, n := range tt.pages {
+				assert.Equal(t, n, len(rec.images[i+1]), "page %d", i+1)
+				for _, img := range rec.images[i+1] {
+					assert.InDelta(t, 60, img.Right, .01)
+					assert.InDelta(t, 24, img.Bottom, .01)
+				}
+			}
+		})
+	}
+}
+
+func TestHeaderFooterBands(t *testing.T) {
+	row := types.PdfTemplateAttributes{"width": "50mm", "height": "5mm", "display": "row"}
+	tests := []struct {
+		name   string
+		header types.PdfTemplateAttributes
+		footer types.PdfTemplateAttributes
+		boxes  map[string]types.Rect
+	}{
+		{
+			name:   "bands",
+			header: types.PdfTemplateAttributes{"height": "20mm", "margin": "8mm"},
+			footer: types.PdfTemplateAttributes{"height": "15mm", "margin": "8mm"},
+			boxes: map[string]types.Rect{
+				"Head 1": {Left: 10, Top: 8, Right: 50, Bottom: 5},
+				"Head 2": {Left: 10, Top: 13, Right: 50, Bottom: 5},
+				"Body":   {Left: 10, Top: 28, Right: 50, Bottom: 5},
+				"Foot":   {Left: 10, Top: 274, Right: 50, Bottom: 5},
+			},
+		},
+		{
+			name: "margins",
+			boxes: map[string]types.Rect{
+				"Head 1": {Left: 10, Top: 2.88, Right: 50, Bottom: 5},
+				"Body":   {Left: 10, Top: 10, Right: 50, Bottom: 5},
+				"Foot":   {Left: 10, Top: 277, Right: 50, Bottom: 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.Header().Attributes(tt.header).AddCell().Text("Head 1").Attributes(row).Parent().AddCell().Text("Head 2").Attributes(row)
+			b.Footer().Attributes(tt.footer).AddCell().Text("Foot").Attributes(row)
+			b.AddPage().AddCell().Text("Body").Attributes(row)
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestSectionBandBoxes(t *testing.T) {
+	fill := types.PdfTemplateAttributes{"width": "100%", "height": "100%"}
+	tests := []struct {
+		name   string
+		header types.PdfTemplateAttributes
+		footer types.PdfTemplateAttributes
+		boxes  map[string]types.Rect
+	}{
+		{
+			name:   "padding",
+			header: types.PdfTemplateAttributes{"height": "20mm", "margin": "8mm", "padding": "2mm 4mm 3mm 5mm"},
+			footer: types.PdfTemplateAttributes{"height": "15mm", "margin": "8mm", "padding": "1mm"},
+			boxes: map[string]types.Rect{
+				"Head": {Left: 15, Top: 10, Right: 181, Bottom: 15},
+				"Body": {Left: 10, Top: 28, Right: 190, Bottom: 5},
+				"Foot": {Left: 11, Top: 275, Right: 188, Bottom: 13},
+			},
+		},
+		{
+			name:   "margins",
+			header: types.PdfTemplateAttributes{"height": "20mm", "margin-top": "8mm", "margin-left": "20mm", "margin-right": "30mm", "margin-bottom": "4mm"},
+			footer: types.PdfTemplateAttributes{"height": "15mm", "margin-bottom": "5mm", "margin-top": "2mm"},
+			boxes: map[string]types.Rect{
+				"Head": {Left: 20, Top: 8, Right: 160, Bottom: 20},
+				"Body": {Left: 10, Top: 32, Right: 190, Bottom: 5},
+				"Foot": {Left: 10, Top: 277, Right: 190, Bottom: 15},
+			},
+		},
+		{
+			name:   "without height",
+			header: types.PdfTemplateAttributes{"padding": "2mm 0mm 4mm"},
+			footer: types.PdfTemplateAttributes{"padding": "1mm"},
+			boxes: map[string]types.Rect{
+				"Head": {Left: 10, Top: 1.88, Right: 190, Bottom: 4},
+				"Body": {Left: 10, Top: 10, Right: 190, Bottom: 5},
+				"Foot": {Left: 11, Top: 278, Right: 188, Bottom: 18},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.Header().Attributes(tt.header).AddCell().Text("Head").Attributes(fill)
+			b.Footer().Attributes(tt.footer).AddCell().Text("Foot").Attributes(fill)
+			b.AddPage().AddCell().Text("Body").Attributes(types.PdfTemplateAttributes{"width": "100%", "height": "5mm"})
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, tt.boxes, rec)
+		})
+	}
+}
+
+func TestSectionVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages []types.PdfTemplateAttributes // attributes of every page
+		want  [][]string
+		err   string
+	}{
+		{
+			name:  "selected by page",
+			pages: []types.PdfTemplateAttributes{nil, nil, nil, nil},
+			want:  [][]string{{"Cover", "Body", "Odd 1"}, {"Report", "Body", "Even 2"}, {"Report", "Body", "Odd 3"}, {"Report", "Body", "The end"}},
+		},
+		{
+			name:  "forced and hidden",
+			pages: []types.PdfTemplateAttributes{{"header": "default"}, {"header": "false"}, {"footer": "even"}, {"footer": "default", "header": "first"}},
+			want:  [][]string{{"Report", "Body", "Odd 1"}, {"Body", "Even 2"}, {"Report", "Body", "Even 3"}, {"Cover", "Body", "Page 4"}},
+		},
+		{
+			name:  "single page",
+			pages: []types.PdfTemplateAttributes{nil},
+			want:  [][]string{{"Cover", "Body", "The end"}},
+		},
+		{
+			name:  "invalid variant",
+			pages: []types.PdfTemplateAttributes{{"header": "middle"}},
+			err:   "error in page 0: attribute `header`: invalid section variant `middle`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.Header().AddCell().Text("Report")
+			b.Header(types.SV_FIRST).AddCell().Text("Cover")
+			b.Footer().AddCell().Text("Page ${page}")
+			b.Footer(types.SV_ODD).AddCell().Text("Odd ${page}")
+			b.Footer(types.SV_EVEN).AddCell().Text("Even ${page}")
+			b.Footer(types.SV_LAST).AddCell().Text("The end")
+			for _, attrs := range tt.pages {
+				b.AddPage().Attributes(attrs).AddCell().Text("Body")
+			}
+			rec, err := render(t, b, nil)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.pages())
+		})
+	}
+}
+
+func TestSectionPageNumbers(t *testing.T) {
+	tests := []struct {
+		name      string
+		number    int // page number of the section, 0 to continue the numbering
+		want      [][]string
+		bookmarks []string
+	}{
+		{
+			name:      "continued",
+			want:      [][]string{{"One", "1 of 4"}, {"Two", "2 of 4"}, {"A", "Appendix 3"}, {"B", "Appendix 4"}},
+			bookmarks: []string{"0 Page 0001 @1", "0 Page 0002 @2", "0 Appendix @3", "1 Appendix page 3 @3", "1 Appendix page 4 @4"},
+		},
+		{
+			name:      "restarted",
+			number:    1,
+			want:      [][]string{{"One", "1 of 4"}, {"Two", "2 of 4"}, {"A", "Appendix 1"}, {"B", "Appendix 2"}},
+			bookmarks: []string{"0 Page 0001 @1", "0 Page 0002 @2", "0 Appendix @3", "1 Appendix page 1 @3", "1 Appendix page 2 @4"},
+		},
+		{
+			name:      "started later",
+			number:    10,
+			want:      [][]string{{"One", "1 of 4"}, {"Two", "2 of 4"}, {"A", "Appendix 10"}, {"B", "Appendix 11"}},
+			bookmarks: []string{"0 Page 0001 @1", "0 Page 0002 @2", "0 Appendix @3", "1 Appendix page 10 @3", "1 Appendix page 11 @4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder().ShowBookmarks(true)
+			b.Footer().AddCell().Text("${page} of ${total}")
+			b.AddPage().AddCell().Text("One")
+			b.AddPage().AddCell().Text("Two")
+			appendix := b.AddSection().BookmarkTitle("Appendix").PageBookmarkTemplate("Appendix page ${page}")
+			if tt.number > 0 {
+				appendix.PageNumber(tt.number)
+			}
+			appendix.Footer().AddCell().Text("Appendix ${page}")
+			appendix.AddPage().AddCell().Text("A")
+			appendix.AddPage().AddCell().Text("B")
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.pages())
+			assert.Equal(t, tt.bookmarks, rec.bookmarks)
+		})
+	}
+}
+
+func TestSectionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(s types.PdfTemplateSection)
+		err   string
+	}{
+		{"no pages", func(s types.PdfTemplateSection) {}, "error in section 0: no page data provided"},
+		{"page number", func(s types.PdfTemplateSection) { s.Attribute("page-number", "0").AddPage() }, "error in section 0: attribute `page-number`: invalid page number `0`, expected a positive number"},
+		{"margin", func(s types.PdfTemplateSection) { s.Attribute("margin", "1mm 2mm 3mm 4mm 5mm").AddPage() }, "error in section 0: attribute `margin`: invalid spacing `1mm 2mm 3mm 4mm 5mm`, expected one to four dimensions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.AddPage()
+			tt.build(b.AddSection())
+			_, err := render(t, b, nil)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestPageSetup(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b types.PdfTemplateBuilder)
+		sizes []string
+		err   string
+	}{
+		{
+			name: "pages",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().PageSetup(types.PO_LANDSCAPE, "")
+				b.AddPage().Attributes(types.PdfTemplateAttributes{"orientation": "landscape", "size": "A5"})
+				b.AddPage().Attribute("size", "100mm x 150mm")
+			},
+			sizes: []string{"210x297", "297x210", "210x148", "100x150"},
+		},
+		{
+			name: "sections",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddSection().PageSetup(types.PO_LANDSCAPE, types.PAGE_SIZE_A5).AddPage()
+				s := b.AddSection().Attribute("orientation", "landscape")
+				s.AddPage()
+				s.AddPage().PageSetup(types.PO_PORTRAIT, "")
+			},
+			sizes: []string{"210x297", "210x148", "297x210", "210x297"},
+		},
+		{
+			name:  "invalid orientation",
+			build: func(b types.PdfTemplateBuilder) { b.AddSection().Attribute("orientation", "upright").AddPage() },
+			err:   "error in section 0: attribute `orientation`: invalid page orientation `upright`, expected `portrait` or `landscape`",
+		},
+		{
+			name:  "invalid size",
+			build: func(b types.PdfTemplateBuilder) { b.AddPage().Attribute("size", "B7") },
+			err:   "error in page 1: attribute `size`: invalid page size `B7`, expected a named size or `WxH`, e.g. `100mm x 150mm`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.AddPage()
+			tt.build(b)
+			rec, err := render(t, b, nil)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.sizes, rec.sizes)
+		})
+	}
+}
+
+func TestLoadSectionOrientation(t *testing.T) {
+	for _, orientation := range []string{"L", "landscape"} {
+		t.Run(orientation, func(t *testing.T) {
+			tpl, err := impl.NewTemplateLoader().LoadR(strings.NewReader(`{"pages": [{"cells": []}], "sections": [{"orientation": "` + orientation + `", "pages": [{"cells": []}]}]}`))
+			assert.Nil(t, err)
+			doc, err := pdf.NewPdfDocument(types.PO_PORTRAIT, types.PAGE_SIZE_A4, types.DU_MILIMETER)
+			assert.Nil(t, err)
+			rec := &recorder{PdfDocument: doc}
+			assert.Nil(t, tpl.RenderF(rec, filepath.Join(t.TempDir(), "out.pdf")))
+			assert.Equal(t, []string{"210x297", "297x210"}, rec.sizes)
+		})
+	}
+
+	_, err := impl.NewTemplateLoader().LoadR(strings.NewReader(`{"sections": [{"orientation": "upright", "pages": [{"cells": []}]}]}`))
+	assert.EqualError(t, err, "error in section 0: invalid page orientation `upright`, expected `portrait` or `landscape`")
+}
+
+func TestPageMargins(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b types.PdfTemplateBuilder) types.PdfTemplatePage
+		want  types.Rect // box of the first cell, 10mm high with the remaining width
+	}{
+		{
+			name:  "defaults",
+			build: func(b types.PdfTemplateBuilder) types.PdfTemplatePage { return b.AddPage() },
+			want:  types.Rect{Left: 10, Top: 10, Right: 190, Bottom: 10},
+		},
+		{
+			name: "document",
+			build: func(b types.PdfTemplateBuilder) types.PdfTemplatePage {
+				return b.Margin("20mm 15mm").AddPage()
+			},
+			want: types.Rect{Left: 15, Top: 20, Right: 180, Bottom: 10},
+		},
+		{
+			name: "section and page sides",
+			build: func(b types.PdfTemplateBuilder) types.PdfTemplatePage {
+				b.Margin("20mm").AddPage()
+				return b.AddSection().Attribute("margin-left", "30mm").AddPage().Attribute("margin-top", "5mm")
+			},
+			want: types.Rect{Left: 30, Top: 5, Right: 160, Bottom: 10},
+		},
+		{
+			name: "custom size",
+			build: func(b types.PdfTemplateBuilder) types.PdfTemplatePage {
+				return b.Margin("5mm").AddPage().Attribute("size", "100mm x 150mm")
+			},
+			want: types.Rect{Left: 5, Top: 5, Right: 90, Bottom: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b).AddCell().Text("First").Attributes(types.PdfTemplateAttributes{"width": "100%", "height": "10mm"})
+			rec, err := render(t, b, nil)
+			assert.Nil(t, err)
+			assertBoxes(t, map[string]types.Rect{"First": tt.want}, rec)
+		})
+	}
+}
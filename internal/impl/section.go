@@ -0,0 +1,73 @@
+package impl
+
+import (
+	"strconv"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+)
+
+type sectionImpl struct {
+	elementImpl[types.PdfTemplateSection]
+	section *types.Section
+}
+
+func (s *sectionImpl) AddPage() types.PdfTemplatePage {
+	var newPage = new(types.Page)
+
+	newPage.Cells = make([]*types.Cell, 0)
+	newPage.Attrs = make([]*types.Attribute, 0)
+
+	s.section.Pages = append(s.section.Pages, newPage)
+	page := &pageImpl{page: newPage}
+	page.self = page
+	page.container.builder = s.container.builder
+	page.container.attributes = &newPage.Attrs
+	return page
+}
+
+func (s *sectionImpl) PageSetup(orientation types.PageOrientation, pageSize types.PageSize) types.PdfTemplateSection {
+	if orientation != "" {
+		s.Attribute("orientation", string(orientation))
+	}
+	if pageSize != "" {
+		s.Attribute("size", string(pageSize))
+	}
+	return s
+}
+
+func (s *sectionImpl) PageNumber(number int) types.PdfTemplateSection {
+	return s.Attribute("page-number", strconv.Itoa(number))
+}
+
+func (s *sectionImpl) PageBookmarkTemplate(template string) types.PdfTemplateSection {
+	s.section.PageBookmarkTemplate = template
+	return s
+}
+
+func (s *sectionImpl) BookmarkTitle(bookmark string) types.PdfTemplateSection {
+	s.section.BookmarkTitle = bookmark
+	return s
+}
+
+func (s *sectionImpl) Header(variant ...types.SectionVariant) types.PdfTemplateHeader {
+	if s.section.Head == nil {
+		s.section.Head = new(types.Header)
+	}
+	return s.container.builder.header(s.section.Head, variant)
+}
+
+func (s *sectionImpl) Footer(variant ...types.SectionVariant) types.PdfTemplateFooter {
+	if s.section.Foot == nil {
+		s.section.Foot = new(types.Footer)
+	}
+	return s.container.builder.footer(s.section.Foot, variant)
+}
+
+func (s *sectionImpl) StyleList(name string, more ...string) types.PdfTemplateSection {
+	if s.section.StyleList == nil {
+		s.section.StyleList = make([]string, 0)
+	}
+	s.section.StyleList = append(s.section.StyleList, name)
+	s.section.StyleList = append(s.section.StyleList, more...)
+	return s
+}
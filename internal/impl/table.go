@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"strconv"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+)
+
+type tableImpl struct {
+	elementImpl[types.PdfTemplateTable]
+	parent types.PdfTemplatePage
+	table  *types.Table
+}
+
+type tableRowImpl struct {
+	elementImpl[types.PdfTemplateTableRow]
+	parent types.PdfTemplateTable
+	row    *types.TableRow
+}
+
+type tableCell struct {
+	elementCell[types.PdfTemplateTableCell, types.PdfTemplateTableRow]
+}
+
+func (p *pageImpl) AddTable() types.PdfTemplateTable {
+	var table types.Table
+	p.page.Cells = append(p.page.Cells, &types.Cell{Table: &table})
+	t := &tableImpl{parent: p, table: &table}
+	t.self = t
+	t.container.builder = p.container.builder
+	t.container.attributes = &table.Attrs
+	return t
+}
+
+func (t *tableImpl) Parent() types.PdfTemplatePage {
+	return t.parent
+}
+
+func (t *tableImpl) Columns(width string, more ...string) types.PdfTemplateTable {
+	for _, w := range append([]string{width}, more...) {
+		t.table.Columns = append(t.table.Columns, &types.TableColumn{Width: w})
+	}
+	return t
+}
+
+func (t *tableImpl) addRow(rows *[]*types.TableRow) types.PdfTemplateTableRow {
+	var row types.TableRow
+	*rows = append(*rows, &row)
+	r := &tableRowImpl{parent: t, row: &row}
+	r.self = r
+	r.container.builder = t.container.builder
+	r.container.attributes = &row.Attrs
+	return r
+}
+
+func (t *tableImpl) AddHeaderRow() types.PdfTemplateTableRow {
+	return t.addRow(&t.table.Header)
+}
+
+func (t *tableImpl) AddRow() types.PdfTemplateTableRow {
+	return t.addRow(&t.table.Rows)
+}
+
+func (t *tableImpl) StyleList(name string, more ...string) types.PdfTemplateTable {
+	t.table.StyleList = append(t.table.StyleList, name)
+	t.table.StyleList = append(t.table.StyleList, more...)
+	return t
+}
+
+func (r *tableRowImpl) Parent() types.PdfTemplateTable {
+	return r.parent
+}
+
+func (r *tableRowImpl) AddCell() types.PdfTemplateTableCell {
+	var newCell types.Cell
+	r.row.Cells = append(r.row.Cells, &newCell)
+	cell := &tableCell{
+		elementCell[types.PdfTemplateTableCell, types.PdfTemplateTableRow]{
+			parent:  r,
+			cell:    &newCell,
+			builder: r.container.builder,
+		},
+	}
+	cell.self = cell
+	cell.container.builder = r.container.builder
+	cell.container.attributes = &newCell.Attrs
+	return cell
+}
+
+func (r *tableRowImpl) Repeat(expression string) types.PdfTemplateTableRow {
+	return r.Attribute("repeat", expression)
+}
+
+func (r *tableRowImpl) StyleList(name string, more ...string) types.PdfTemplateTableRow {
+	r.row.StyleList = append(r.row.StyleList, name)
+	r.row.StyleList = append(r.row.StyleList, more...)
+	return r
+}
+
+func (c *tableCell) ColSpan(columns int) types.PdfTemplateTableCell {
+	return c.Attribute("colspan", strconv.Itoa(columns))
+}
+
+func (c *tableCell) RowSpan(rows int) types.PdfTemplateTableCell {
+	return c.Attribute("rowspan", strconv.Itoa(rows))
+}
@@ -0,0 +1,463 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Table cell resolved against the data and placed on the table grid
+type resolvedCell struct {
+	cell  *types.Cell
+	scope *expr.Scope
+
+	row, col         int // top left slot
+	rowspan, colspan int
+	w, h             float64 // measured size
+}
+
+// Table row resolved against the data. Repeated rows yield one resolvedRow per element.
+type resolvedRow struct {
+	row    *types.TableRow
+	cells  []*resolvedCell // cells starting on this row
+	height float64
+}
+
+// Rows of a table laid out on a grid of slots. Spanning cells occupy several slots.
+type tableGrid struct {
+	rows    []*resolvedRow
+	slots   [][]*resolvedCell // [row][column]
+	ncols   int
+	nheader int // number of header rows, which come first
+}
+
+// Expands repeated rows and drops the rows whose condition does not hold
+func (r *renderer) expandRows(kind string, rows []*types.TableRow, scope *expr.Scope) ([]*resolvedRow, error) {
+	expanded := []*resolvedRow{}
+	for i, row := range rows {
+		scopes, err := r.repeatScopes(row.Repeat, scope)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %d", kind, i)
+		}
+		for _, s := range scopes {
+			visible, err := r.test(row.Condition, s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %d", kind, i)
+			}
+			if !visible {
+				continue
+			}
+			tr := &resolvedRow{row: row}
+			if err := r.expandCells(&tr.cells, row.Cells, s); err != nil {
+				return nil, errors.Wrapf(err, "%s %d", kind, i)
+			}
+			expanded = append(expanded, tr)
+		}
+	}
+	return expanded, nil
+}
+
+// Expands repeated cells and flattens groups, each resulting cell taking up its own slot
+func (r *renderer) expandCells(dst *[]*resolvedCell, cells []*types.Cell, scope *expr.Scope) error {
+	for i, cell := range cells {
+		scopes, err := r.repeatScopes(cell.Repeat, scope)
+		if err != nil {
+			return errors.Wrapf(err, "cell %d", i)
+		}
+		for _, s := range scopes {
+			visible, err := r.test(cell.Condition, s)
+			if err != nil {
+				return errors.Wrapf(err, "cell %d", i)
+			}
+			if !visible {
+				continue
+			}
+			if len(cell.Cells) > 0 {
+				if err := r.expandCells(dst, cell.Cells, s); err != nil {
+					return errors.Wrapf(err, "cell %d", i)
+				}
+				continue
+			}
+			styled, err := r.styleCell(cell, s)
+			if err != nil {
+				return errors.Wrapf(err, "cell %d", i)
+			}
+			*dst = append(*dst, &resolvedCell{cell: styled, scope: s, rowspan: max(cell.RowSpan, 1), colspan: max(cell.ColSpan, 1)})
+		}
+	}
+	return nil
+}
+
+// Places the cells on the grid, left to right, skipping slots taken by cells spanning from the rows above.
+// Row spans do not cross from the header into the body.
+func newTableGrid(header, body []*resolvedRow) *tableGrid {
+	g := &tableGrid{rows: append(append([]*resolvedRow{}, header...), body...), nheader: len(header)}
+	g.slots = make([][]*resolvedCell, len(g.rows))
+	for i, tr := range g.rows {
+		end := len(g.rows)
+		if i < g.nheader {
+			end = g.nheader
+		}
+		col := 0
+		for _, rc := range tr.cells {
+			for col < len(g.slots[i]) && g.slots[i][col] != nil {
+				col++
+			}
+			rc.row, rc.col = i, col
+			rc.rowspan = min(rc.rowspan, end-i)
+			for row := i; row < i+rc.rowspan; row++ {
+				for len(g.slots[row]) < col+rc.colspan {
+					g.slots[row] = append(g.slots[row], nil)
+				}
+				for c := col; c < col+rc.colspan; c++ {
+					g.slots[row][c] = rc
+				}
+			}
+			col += rc.colspan
+		}
+		g.ncols = max(g.ncols, len(g.slots[i]))
+	}
+	return g
+}
+
+// Returns the cell occupying the slot, if any
+func (g *tableGrid) slot(row, col int) *resolvedCell {
+	if row < 0 || row >= len(g.slots) || col < 0 || col >= len(g.slots[row]) {
+		return nil
+	}
+	return g.slots[row][col]
+}
+
+// Returns the index of the row following the block starting at row. Rows joined by row spans form a block,
+// which is kept on one page.
+func (g *tableGrid) blockEnd(row int) int {
+	end := row + 1
+	for i := row; i < end; i++ {
+		for _, rc := range g.rows[i].cells {
+			end = max(end, rc.row+rc.rowspan)
+		}
+	}
+	return end
+}
+
+// Returns the size the cell needs, its explicit width and height taking precedence over its text or image
+func (r *renderer) measureTableCell(c types.Canvas, rc *resolvedCell) (w, h float64, err error) {
+	cell, err := r.resolveCell(rc.cell, rc.scope)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rc.cell.ImageData != nil {
+		w, h = c.MeasureImage(rc.cell.ImageData)
+	} else {
+		w, h = cell.MeasureText(c, math.Inf(1))
+	}
+	dc := c.GetDrawingRect()
+	_, padding := rc.cell.GetSpacing(c, r.doc, dc)
+	w, h = w+padding.Left+padding.Right, h+padding.Top+padding.Bottom
+	if rc.cell.Width != nil && rc.cell.Width.Unit != types.DU_PERCENT {
+		w = rc.cell.Width.GetValue(dc.Right, 0, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+	}
+	if rc.cell.Height != nil {
+		h = rc.cell.Height.GetValue(0, dc.Bottom, 0, types.UT_LENGTH|types.UT_LENGTH_HEIGHT, r.doc.DisplayUnit)
+	}
+	return w, h, nil
+}
+
+// Resolves the column widths. Fixed and relative columns take their size, auto columns share what is left
+// in proportion to their content and are shrunk when their content does not fit.
+// Spanning cells widen the auto columns they span when they need more room.
+func (r *renderer) columnWidths(table *types.Table, g *tableGrid, tableW float64) []float64 {
+	widths := make([]float64, max(g.ncols, len(table.Columns)))
+	isAuto := func(col int) bool {
+		return col >= len(table.Columns) || table.Columns[col].IsAuto()
+	}
+	fixedW := 0.
+	for i := range widths {
+		if !isAuto(i) {
+			widths[i] = table.Columns[i].Size.GetValue(tableW, 0, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+			fixedW += widths[i]
+		}
+	}
+	for _, tr := range g.rows {
+		for _, rc := range tr.cells {
+			if rc.colspan == 1 && isAuto(rc.col) {
+				widths[rc.col] = max(widths[rc.col], rc.w)
+			}
+		}
+	}
+	for _, tr := range g.rows {
+		for _, rc := range tr.cells {
+			if rc.colspan == 1 {
+				continue
+			}
+			spanW, auto := 0., []int{}
+			for i := rc.col; i < rc.col+rc.colspan; i++ {
+				spanW += widths[i]
+				if isAuto(i) {
+					auto = append(auto, i)
+				}
+			}
+			if rc.w > spanW && len(auto) > 0 {
+				for _, i := range auto {
+					widths[i] += (rc.w - spanW) / float64(len(auto))
+				}
+			}
+		}
+	}
+
+	auto, autoW := 0, 0.
+	for i := range widths {
+		if isAuto(i) {
+			auto++
+			autoW += widths[i]
+		}
+	}
+	if auto == 0 {
+		return widths
+	}
+	remaining := max(tableW-fixedW, 0)
+	for i := range widths {
+		if !isAuto(i) {
+			continue
+		}
+		if autoW > remaining {
+			widths[i] *= remaining / autoW
+		} else {
+			widths[i] += (remaining - autoW) / float64(auto)
+		}
+	}
+	return widths
+}
+
+// Measures the height of the cells with wrapped text and no height again, in the width of the columns they span
+func (r *renderer) wrapTableCells(c types.Canvas, g *tableGrid, widths []float64) error {
+	dc := c.GetDrawingRect()
+	for i, tr := range g.rows {
+		for j, rc := range tr.cells {
+			if !rc.cell.TextStyle.Wrap || rc.cell.Height != nil || rc.cell.ImageData != nil {
+				continue
+			}
+			cell, err := r.resolveCell(rc.cell, rc.scope)
+			if err != nil {
+				return errors.Wrapf(err, "cell %d of row %d", j, i)
+			}
+			spanW := 0.
+			for col := rc.col; col < rc.col+rc.colspan; col++ {
+				spanW += widths[col]
+			}
+			_, padding := rc.cell.GetSpacing(c, r.doc, dc)
+			_, h := cell.MeasureText(c, spanW-padding.Left-padding.Right)
+			rc.h = h + padding.Top + padding.Bottom
+		}
+	}
+	return nil
+}
+
+// Resolves the row heights: the tallest cell, but no less than the row height.
+// Cells spanning several rows grow the last row they span when they need more room.
+func (r *renderer) rowHeights(c types.Canvas, g *tableGrid) {
+	for _, tr := range g.rows {
+		tr.height = 0
+		if tr.row.Height != nil {
+			tr.height = tr.row.Height.GetValue(0, c.GetDrawingRect().Bottom, 0, types.UT_LENGTH|types.UT_LENGTH_HEIGHT, r.doc.DisplayUnit)
+		}
+		for _, rc := range tr.cells {
+			if rc.rowspan == 1 {
+				tr.height = max(tr.height, rc.h)
+			}
+		}
+	}
+	for _, tr := range g.rows {
+		for _, rc := range tr.cells {
+			if rc.rowspan == 1 {
+				continue
+			}
+			spanH := 0.
+			for i := rc.row; i < rc.row+rc.rowspan; i++ {
+				spanH += g.rows[i].height
+			}
+			if rc.h > spanH {
+				g.rows[rc.row+rc.rowspan-1].height += rc.h - spanH
+			}
+		}
+	}
+}
+
+// Draws rows [from, to) at y and returns the y below them. above is the row drawn right above from, -1 if none.
+// Cells with a bookmark are bookmarked at their top when bookmarks is set.
+// Cells are drawn first, then their borders so that backgrounds do not hide the borders of neighbouring cells.
+// Edges shared by adjacent cells are stroked once, by the cell on the left or above, unless that cell has no border on that edge.
+func (r *renderer) drawTableRows(c types.Canvas, g *tableGrid, from, to, above int, widths []float64, x, y float64, ctx *cellContext, bookmarks bool) (float64, error) {
+	xs := make([]float64, len(widths)+1)
+	xs[0] = x
+	for i, w := range widths {
+		xs[i+1] = xs[i] + w
+	}
+	ys := make([]float64, to-from+1)
+	ys[0] = y
+	for i := from; i < to; i++ {
+		ys[i-from+1] = ys[i-from] + g.rows[i].height
+	}
+
+	for i := from; i < to; i++ {
+		for _, rc := range g.rows[i].cells {
+			cell, err := r.resolveCell(rc.cell, rc.scope)
+			if err != nil {
+				return y, errors.Wrapf(err, "row %d", i)
+			}
+			cell.Width = types.NewDimension(xs[rc.col+rc.colspan]-xs[rc.col], r.doc.DisplayUnit)
+			cell.Height = types.NewDimension(ys[rc.row+rc.rowspan-from]-ys[rc.row-from], r.doc.DisplayUnit)
+			cell.Margin = types.Spacing{}
+			cell.Border.Left, cell.Border.Top, cell.Border.Right, cell.Border.Bottom = nil, nil, nil, nil
+			if bookmarks {
+				if err := r.bookmarkCell(cell, ctx, rc.scope, ys[rc.row-from]); err != nil {
+					return y, errors.Wrapf(err, "row %d", i)
+				}
+			}
+			c.SetXY(xs[rc.col], ys[rc.row-from])
+			cell.Render(c, rc.col, r.doc, ctx.page, false)
+		}
+	}
+
+	for i := from; i < to; i++ {
+		for _, rc := range g.rows[i].cells {
+			edges := types.BE_RIGHT | types.BE_BOTTOM
+			for row := rc.row; row < rc.row+rc.rowspan; row++ {
+				if left := g.slot(row, rc.col-1); left == nil || left.cell.Border.Right == nil {
+					edges |= types.BE_LEFT
+				}
+			}
+			rowAbove := rc.row - 1
+			if rc.row == from {
+				rowAbove = above
+			}
+			for col := rc.col; col < rc.col+rc.colspan; col++ {
+				if top := g.slot(rowAbove, col); top == nil || top.cell.Border.Bottom == nil {
+					edges |= types.BE_TOP
+				}
+			}
+			rc.cell.DrawBorderEdges(c, xs[rc.col], ys[rc.row-from], xs[rc.col+rc.colspan], ys[rc.row+rc.rowspan-from], edges)
+		}
+	}
+	return ys[len(ys)-1], nil
+}
+
+// Renders the table hosted by the cell. Tables on pages continue on a new page when a row does not fit,
+// repeating the header rows at the top of every page. Rows joined by row spans are kept together.
+func (r *renderer) renderTable(c types.Canvas, host *types.Cell, ctx *cellContext, scope *expr.Scope) error {
+	table := host.Table
+	header, err := r.expandRows("header row", table.Header, scope)
+	if err != nil {
+		return err
+	}
+	body, err := r.expandRows("row", table.Rows, scope)
+	if err != nil {
+		return err
+	}
+	g := newTableGrid(header, body)
+	for i, tr := range g.rows {
+		for j, rc := range tr.cells {
+			if rc.w, rc.h, err = r.measureTableCell(c, rc); err != nil {
+				return errors.Wrapf(err, "cell %d of row %d", j, i)
+			}
+		}
+	}
+
+	// tables without a width take the rest of the line
+	dc := c.GetDrawingRect()
+	left := c.GetX()
+	if ctx.isPageCell && host.Absolute {
+		left = host.Left
+	}
+	tableW := dc.Left + dc.Right - left
+	if table.Width != nil {
+		tableW = table.Width.GetValue(dc.Right, 0, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+	}
+	widths := r.columnWidths(table, g, tableW)
+	if err := r.wrapTableCells(c, g, widths); err != nil {
+		return err
+	}
+	r.rowHeights(c, g)
+	blockH := func(from, to int) (h float64) {
+		for i := from; i < to; i++ {
+			h += g.rows[i].height
+		}
+		return
+	}
+
+	if ctx.beforeCell != nil {
+		ctx.beforeCell()
+	}
+
+	breaks := ctx.isPageCell && !host.Absolute
+	if breaks {
+		// keep the header together with the first block
+		first := blockH(0, g.nheader)
+		if len(body) > 0 {
+			first += blockH(g.nheader, g.blockEnd(g.nheader))
+		}
+		if err := r.ensureSpace(c, ctx, first); err != nil {
+			return err
+		}
+	}
+
+	x, y := c.GetXY()
+	if ctx.isPageCell && host.Absolute {
+		x, y = host.Left, host.Top
+	}
+	if err := r.bookmarkCell(host, ctx, scope, y); err != nil {
+		return err
+	}
+
+	top := y // top of the table on the current page
+	if y, err = r.drawTableRows(c, g, 0, g.nheader, -1, widths, x, y, ctx, true); err != nil {
+		return err
+	}
+	above := g.nheader - 1 // last row drawn on the current page
+	drawn := 0             // body rows drawn on the current page
+	for from := g.nheader; from < len(g.rows); {
+		to := g.blockEnd(from)
+		if breaks && drawn > 0 && y+blockH(from, to) > dc.Top+dc.Bottom {
+			if err = r.endPage(c, ctx.page); err != nil {
+				return err
+			}
+			if c, err = r.beginPage(ctx, false); err != nil {
+				return err
+			}
+			dc = c.GetDrawingRect()
+			y = c.GetY()
+			top = y
+			drawn, above = 0, -1
+			if table.RepeatHeader {
+				// repeated header rows keep the bookmarks of their first page
+				if y, err = r.drawTableRows(c, g, 0, g.nheader, -1, widths, x, y, ctx, false); err != nil {
+					return err
+				}
+				above = g.nheader - 1
+			}
+		}
+		if y, err = r.drawTableRows(c, g, from, to, above, widths, x, y, ctx, true); err != nil {
+			return err
+		}
+		drawn += to - from
+		above = to - 1
+		from = to
+	}
+
+	// move past the table like the display style of its cell does
+	switch host.TextStyle.DisplayStyle {
+	case types.DISPLAY_ROW:
+		c.SetXY(dc.Left, y)
+	case types.DISPLAY_STACK:
+		c.SetXY(x, y)
+	default:
+		drawnW := 0.
+		for _, w := range widths {
+			drawnW += w
+		}
+		c.SetXY(x+drawnW, top)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package impl_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/impl"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTablePages(t *testing.T) {
+	entries := make([]int, 40)
+	for i := range entries {
+		entries[i] = i + 1
+	}
+	tests := []struct {
+		repeatHeader string
+		want         [][]string // first two and last texts of every page
+	}{
+		{"true", [][]string{{"#", "1", "25"}, {"#", "26", "40"}}},
+		{"false", [][]string{{"#", "1", "25"}, {"26", "27", "40"}}},
+	}
+	for _, tt := range tests {
+		t.Run("repeat header "+tt.repeatHeader, func(t *testing.T) {
+			b := newBuilder()
+			table := b.AddPage().AddTable().Columns("auto").Attribute("repeat-header", tt.repeatHeader)
+			table.AddHeaderRow().Attribute("height", "10mm").AddCell().Text("#")
+			table.AddRow().Repeat("entries").Attribute("height", "10mm").AddCell().Text("${item}")
+			rec, err := render(t, b, map[string]any{"entries": entries})
+			assert.Nil(t, err)
+
+			got := [][]string{}
+			for _, page := range rec.pages() {
+				got = append(got, []string{page[0], page[1], page[len(page)-1]})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTableErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(table types.PdfTemplateTable)
+		err   string
+	}{
+		{
+			name:  "column width",
+			build: func(table types.PdfTemplateTable) { table.Columns("auto", "wide").AddRow().AddCell().Text("A") },
+			err:   "error in cell 0 of page 0: table: column 1: invalid unit wide",
+		},
+		{
+			name: "row attribute",
+			build: func(table types.PdfTemplateTable) {
+				table.Columns("auto").AddRow().Attribute("height", "tall").AddCell().Text("A")
+			},
+			err: "error in cell 0 of page 0: table: row 0: attribute `height`: invalid unit tall",
+		},
+		{
+			name:  "repeat",
+			build: func(table types.PdfTemplateTable) { table.Columns("auto").AddRow().Repeat("count").AddCell().Text("A") },
+			err:   "page 0: cell 0: row 0: `count`: cannot repeat over number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b.AddPage().AddTable())
+			_, err := render(t, b, map[string]any{"count": 1})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestTableSpans(t *testing.T) {
+	tests := []struct {
+		name  string
+		fill  int // rows before the spanning rows
+		pages int
+		last  []string // texts of the last page
+	}{
+		{"fits", 2, 1, []string{"1", "2", "A", "b1", "b2", "b3", "Total"}},
+		{"moved to the next page", 24, 2, []string{"A", "b1", "b2", "b3", "Total"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fill := make([]int, tt.fill)
+			for i := range fill {
+				fill[i] = i + 1
+			}
+			b := newBuilder()
+			table := b.AddPage().AddTable().Columns("auto", "auto")
+			table.AddRow().Repeat("fill").Attribute("height", "10mm").AddCell().Text("${item}").Parent().AddCell()
+			table.AddRow().Attribute("height", "10mm").
+				AddCell().Text("A").RowSpan(3).Parent().
+				AddCell().Text("b1")
+			table.AddRow().Attribute("height", "10mm").AddCell().Text("b2")
+			table.AddRow().Attribute("height", "10mm").AddCell().Text("b3")
+			table.AddRow().AddCell().Text("Total").ColSpan(2)
+			rec, err := render(t, b, map[string]any{"fill": fill})
+			assert.Nil(t, err)
+			pages := rec.pages()
+			assert.Equal(t, tt.pages, len(pages))
+			assert.Equal(t, tt.last, pages[len(pages)-1])
+		})
+	}
+}
+
+func TestTableCursor(t *testing.T) {
+	tests := []struct {
+		display string
+		next    types.Rect
+	}{
+		{"row", types.Rect{Left: 10, Top: 18, Right: 20, Bottom: 8}},
+		{"stack", types.Rect{Left: 40, Top: 18, Right: 20, Bottom: 8}},
+		{"column", types.Rect{Left: 140, Top: 10, Right: 20, Bottom: 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.display, func(t *testing.T) {
+			tpl, err := impl.NewTemplateLoader().LoadR(strings.NewReader(`{"size": "A4", "units": "mm", "pages": [{"cells": [
+				{"text": "Label", "attributes": [{"name": "width", "value": "30mm"}, {"name": "height", "value": "8mm"}]},
+				{"attributes": [{"name": "display", "value": "` + tt.display + `"}], "table": {
+					"attributes": [{"name": "width", "value": "100mm"}],
+					"columns": [{"width": "50%"}, {"width": "50%"}],
+					"rows": [{"attributes": [{"name": "height", "value": "8mm"}], "cells": [{"text": "A"}, {"text": "B"}]}]}},
+				{"text": "Next", "attributes": [{"name": "width", "value": "20mm"}, {"name": "height", "value": "8mm"}]}]}]}`))
+			assert.Nil(t, err)
+			rec := newRecorder(t, tpl)
+			assert.Nil(t, tpl.RenderF(rec, filepath.Join(t.TempDir(), "out.pdf")))
+			assertBoxes(t, map[string]types.Rect{
+				"B":    {Left: 90, Top: 10, Right: 50, Bottom: 8},
+				"Next": tt.next,
+			}, rec)
+		})
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	b := newBuilder()
+	page := b.AddPage()
+	page.AddCell().Text("Label").Attributes(types.PdfTemplateAttributes{"width": "30mm", "height": "8mm"})
+	page.AddTable().Columns("50%", "50%").AddRow().Attribute("height", "8mm").
+		AddCell().Text("A").Parent().
+		AddCell().Text("B")
+	rec, err := render(t, b, nil)
+	assert.Nil(t, err)
+	assertBoxes(t, map[string]types.Rect{
+		"A": {Left: 40, Top: 10, Right: 80, Bottom: 8},
+		"B": {Left: 120, Top: 10, Right: 80, Bottom: 8},
+	}, rec)
+}
@@ -64,7 +64,7 @@ func (b *BuilderImpl) Builder() types.PdfTemplateBuilder {
 }
 
 func (b *BuilderImpl) Build() (types.PdfTemplate, error) {
-	if b.document.Pages == nil || len(b.document.Pages) == 0 {
+	if len(b.document.Pages) == 0 && len(b.document.Sections) == 0 {
 		return nil, errors.New("document needs at least one page")
 	}
 	if b.document.Head.Attrs == nil {
@@ -95,6 +95,11 @@ func (b *BuilderImpl) Title(title string) types.PdfTemplateBuilder {
 	return b
 }
 
+func (b *BuilderImpl) Margin(margin string) types.PdfTemplateBuilder {
+	b.container.Attribute("margin", margin)
+	return b
+}
+
 func (b *BuilderImpl) StyleList(name string, more ...string) types.PdfTemplateBuilder {
 	if b.document.StyleList == nil {
 		b.document.StyleList = make([]string, 0)
@@ -146,6 +151,11 @@ func (b *BuilderImpl) AddFontFromFile(fontFamily string, style types.FontStyle,
 	return b
 }
 
+func (b *BuilderImpl) AddImage(name, source string) types.PdfTemplateBuilder {
+	b.document.Images = append(b.document.Images, &types.Image{Name: name, Source: source})
+	return b
+}
+
 func (b *BuilderImpl) AddPage() types.PdfTemplatePage {
 	var newPage = new(types.Page)
 
@@ -160,34 +170,86 @@ func (b *BuilderImpl) AddPage() types.PdfTemplatePage {
 	return page
 }
 
-func (b *BuilderImpl) Header() types.PdfTemplateHeader {
-	header := &headerImpl{header: &b.document.Head}
-	if b.document.Head.Attrs == nil {
-		b.document.Head.Attrs = make([]*types.Attribute, 0)
+func (b *BuilderImpl) AddSection() types.PdfTemplateSection {
+	var newSection = new(types.Section)
+
+	newSection.Pages = make([]*types.Page, 0)
+	newSection.Attrs = make([]*types.Attribute, 0)
+
+	b.document.Sections = append(b.document.Sections, newSection)
+	section := &sectionImpl{section: newSection}
+	section.self = section
+	section.container.builder = b
+	section.container.attributes = &newSection.Attrs
+	return section
+}
+
+func (b *BuilderImpl) Header(variant ...types.SectionVariant) types.PdfTemplateHeader {
+	return b.header(&b.document.Head, variant)
+}
+func (b *BuilderImpl) Footer(variant ...types.SectionVariant) types.PdfTemplateFooter {
+	return b.footer(&b.document.Foot, variant)
+}
+
+// Returns the header, or its variant when one is given
+func (b *BuilderImpl) header(head *types.Header, variant []types.SectionVariant) types.PdfTemplateHeader {
+	if len(variant) > 0 && variant[0] != types.SV_DEFAULT {
+		if head.Variants == nil {
+			head.Variants = make(map[types.SectionVariant]*types.Header)
+		}
+		parent := head
+		if head = parent.Variants[variant[0]]; head == nil {
+			head = new(types.Header)
+			parent.Variants[variant[0]] = head
+		}
 	}
-	if b.document.Head.Cells == nil {
-		b.document.Head.Cells = make([]*types.Cell, 0)
+	header := &headerImpl{header: head}
+	if head.Attrs == nil {
+		head.Attrs = make([]*types.Attribute, 0)
+	}
+	if head.Cells == nil {
+		head.Cells = make([]*types.Cell, 0)
 	}
 	header.self = header
 	header.container.builder = b
-	header.container.attributes = &b.document.Head.Attrs
+	header.container.attributes = &head.Attrs
 	return header
 }
-func (b *BuilderImpl) Footer() types.PdfTemplateFooter {
-	footer := &footerImpl{footer: &b.document.Foot}
-	if b.document.Foot.Attrs == nil {
-		b.document.Foot.Attrs = make([]*types.Attribute, 0)
+
+// Returns the footer, or its variant when one is given
+func (b *BuilderImpl) footer(foot *types.Footer, variant []types.SectionVariant) types.PdfTemplateFooter {
+	if len(variant) > 0 && variant[0] != types.SV_DEFAULT {
+		if foot.Variants == nil {
+			foot.Variants = make(map[types.SectionVariant]*types.Footer)
+		}
+		parent := foot
+		if foot = parent.Variants[variant[0]]; foot == nil {
+			foot = new(types.Footer)
+			parent.Variants[variant[0]] = foot
+		}
 	}
-	if b.document.Foot.Cells == nil {
-		b.document.Foot.Cells = make([]*types.Cell, 0)
+	footer := &footerImpl{footer: foot}
+	if foot.Attrs == nil {
+		foot.Attrs = make([]*types.Attribute, 0)
+	}
+	if foot.Cells == nil {
+		foot.Cells = make([]*types.Cell, 0)
 	}
 	footer.self = footer
 	footer.container.builder = b
-	footer.container.attributes = &b.document.Foot.Attrs
+	footer.container.attributes = &foot.Attrs
 	return footer
 }
 func (b *BuilderImpl) Watermark(text string) types.PdfTemplateWatermark {
 	b.document.Watermark.Text = text
+	return b.watermark()
+}
+
+func (b *BuilderImpl) WatermarkImage(source string) types.PdfTemplateWatermark {
+	return b.watermark().Image(source)
+}
+
+func (b *BuilderImpl) watermark() types.PdfTemplateWatermark {
 	if b.document.Watermark.Attributes == nil {
 		b.document.Watermark.Attributes = make([]*types.Attribute, 0)
 	}
@@ -2,9 +2,13 @@ package impl
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strings"
 
+	"github.com/gintec-rdl/pdf-go/internal/expr"
 	"github.com/gintec-rdl/pdf-go/pkg/types"
 	"github.com/pkg/errors"
 )
@@ -43,7 +47,7 @@ func NewTemplateLoader() types.PdfTemplateLoader {
 }
 
 func validateDocument(doc *types.Document) error {
-	if len(doc.Pages) == 0 {
+	if len(doc.Pages) == 0 && len(doc.Sections) == 0 {
 		return errors.New("no page data provided")
 	}
 
@@ -51,6 +55,11 @@ func validateDocument(doc *types.Document) error {
 	if doc.DisplayUnit == types.DU_PERCENT {
 		return errors.New("relative units cannot be used at the document level")
 	}
+	if doc.PageSize != "" {
+		if err := doc.PageSize.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// apply defaults
 	doc.TextStyle.FontSize.Value = 12
@@ -71,92 +80,269 @@ func validateDocument(doc *types.Document) error {
 	doc.Brush.StrokeWidth = .2
 
 	attrWalker := func(prefix string, attrs []*types.Attribute, e types.IElement, parent types.IElement, specialHandlerOnly ...bool) error {
-		if prefix != "" {
-			prefix = prefix + "."
-		}
-		// combine in order of (defined list, inline list) to allow overriding
-		combinedAttributes := []*types.Attribute{}
-		styles := e.GetElement().StyleList
-		for _, styleName := range styles {
-			style, ok := doc.GetStyleByName(styleName)
+		el := e.GetElement()
+		combinedAttributes, conditionalStyles, err := combineAttributes(doc, el.StyleList, attrs)
+		if err != nil {
+			return err
+		}
+		if err := applyAttributes(prefix, combinedAttributes, e, parent, len(specialHandlerOnly) > 0 && specialHandlerOnly[0]); err != nil {
+			return err
+		}
+		if len(conditionalStyles) > 0 {
+			cell, ok := e.(*types.Cell)
 			if !ok {
-				return errors.Errorf("`%s`: style does not exist", styleName)
+				return errors.New("conditional styles are only supported on cells")
+			}
+			if len(cell.Cells) > 0 && !cell.IsContainer() {
+				return errors.New("conditional styles are not supported on group cells")
 			}
-			combinedAttributes = append(combinedAttributes, style.Attributes...)
 		}
-		combinedAttributes = append(combinedAttributes, attrs...)
-		for _, attr := range combinedAttributes {
-			var genOk bool
-			var genericHandler AttributeHandler
-
-			if len(specialHandlerOnly) == 0 || !specialHandlerOnly[0] {
-				genericHandler, genOk = attributeHandlers[attr.Name]
+		el.ConditionalStyles = conditionalStyles
+		if cell, ok := e.(*types.Cell); ok && (cell.Text != "" || len(cell.Spans) > 0) {
+			if strings.ContainsAny(cell.TextStyle.Alignment, "Jj") && !cell.TextStyle.Wrap {
+				return errors.New("justified text alignment `J` requires `wrap`")
+			}
+		}
+		if cell, ok := e.(*types.Cell); ok && cell.Image != "" {
+			if cell.ImageData, err = doc.ResolveImage(cell.Image); err != nil {
+				return errors.Wrap(err, "image")
 			}
+		}
+		return nil
+	}
 
-			specialHandler, specOk := attributeHandlers[prefix+attr.Name]
-			if genOk {
-				if err := genericHandler(e, parent, attr.Value); err != nil {
-					return errors.Wrapf(err, "attribute `%s`", attr.Name)
-				}
+	// child cells of groups and tables hosted by cells
+	var childWalker func(parent *types.Cell) error
+	var tableWalker func(table *types.Table, host *types.Cell) error
+	childWalker = func(parent *types.Cell) error {
+		if parent.Table != nil && len(parent.Cells) > 0 {
+			return errors.New("a cell cannot hold both child cells and a table")
+		}
+		if parent.Image != "" && (parent.Table != nil || len(parent.Cells) > 0) {
+			return errors.New("a cell cannot hold both an image and child cells or a table")
+		}
+		if len(parent.Spans) > 0 && (parent.Text != "" || parent.Image != "" || parent.Table != nil || len(parent.Cells) > 0) {
+			return errors.New("a cell cannot hold both spans and text, an image, child cells or a table")
+		}
+		if parent.ContentType == types.CT_MARKDOWN && (parent.Image != "" || parent.Table != nil || len(parent.Cells) > 0) {
+			return errors.New("markdown cells cannot hold an image, child cells or a table")
+		}
+		if parent.Toc != nil && (parent.Text != "" || len(parent.Spans) > 0 || parent.Image != "" || parent.Table != nil || len(parent.Cells) > 0) {
+			return errors.New("a cell cannot hold both a table of contents and text, spans, an image, child cells or a table")
+		}
+		for i, span := range parent.Spans {
+			inheritSpan(span, parent)
+			if err := attrWalker("span", span.Attrs, span, parent); err != nil {
+				return errors.Wrapf(err, "span %d", i)
 			}
-			if specOk {
-				if err := specialHandler(e, parent, attr.Value); err != nil {
-					return errors.Wrapf(err, "attribute `%s`", attr.Name)
-				}
+		}
+		for i, child := range parent.Cells {
+			child.Inherit(&parent.Element)
+			if err := attrWalker("cell", child.Attrs, child, parent); err != nil {
+				return errors.Wrapf(err, "child cell %d", i)
 			}
-			if !genOk && !specOk {
-				return errors.Errorf("unsupported attribute `%s`", attr.Name)
+			if area := child.GridItem.Area; area != "" && parent.Layout == types.LM_GRID && parent.Grid.Areas[area] == nil {
+				return errors.Errorf("child cell %d: grid area `%s` is not defined by its container", i, area)
+			}
+			if err := childWalker(child); err != nil {
+				return errors.Wrapf(err, "child cell %d", i)
+			}
+		}
+		if parent.Table != nil {
+			if err := tableWalker(parent.Table, parent); err != nil {
+				return errors.Wrap(err, "table")
+			}
+		}
+		if toc := parent.Toc; toc != nil {
+			toc.Leader = "."
+			toc.Inherit(&parent.Element)
+			if err := attrWalker("toc", toc.Attrs, toc, parent); err != nil {
+				return errors.Wrap(err, "table of contents")
 			}
 		}
 		return nil
 	}
+	tableWalker = func(table *types.Table, host *types.Cell) error {
+		table.RepeatHeader = true
+		table.Inherit(&host.Element)
+		if err := attrWalker("table", table.Attrs, table, host); err != nil {
+			return err
+		}
+		for i, col := range table.Columns {
+			if err := col.Parse(); err != nil {
+				return errors.Wrapf(err, "column %d", i)
+			}
+		}
+		rowWalker := func(kind string, rows []*types.TableRow) error {
+			for i, row := range rows {
+				row.Inherit(&table.Element)
+				row.InheritBorders(&table.Element)
+				if err := attrWalker("row", row.Attrs, row, table); err != nil {
+					return errors.Wrapf(err, "%s %d", kind, i)
+				}
+				for j, cell := range row.Cells {
+					if cell.Table != nil {
+						return errors.Errorf("cell %d of %s %d: tables cannot be nested", j, kind, i)
+					}
+					if cell.Toc != nil {
+						return errors.Errorf("cell %d of %s %d: tables of contents are not supported inside tables", j, kind, i)
+					}
+					cell.Inherit(&row.Element)
+					cell.InheritBorders(&row.Element)
+					if err := attrWalker("cell", cell.Attrs, cell, row); err != nil {
+						return errors.Wrapf(err, "cell %d of %s %d", j, kind, i)
+					}
+					if err := childWalker(cell); err != nil {
+						return errors.Wrapf(err, "cell %d of %s %d", j, kind, i)
+					}
+				}
+			}
+			return nil
+		}
+		if err := rowWalker("header row", table.Header); err != nil {
+			return err
+		}
+		return rowWalker("row", table.Rows)
+	}
+
+	// document images
+	for i, img := range doc.Images {
+		if img.Name == "" {
+			return errors.Errorf("image %d: missing name", i)
+		}
+		data, err := types.LoadImage(img.Source)
+		if err != nil {
+			return errors.Wrapf(err, "image `%s`", img.Name)
+		}
+		img.Data = data
+	}
 
 	// document attributes
 	if err := attrWalker("document", doc.Attrs, doc, nil); err != nil {
 		return errors.Wrapf(err, "error in document")
 	}
 
-	// inherit document font style for watermark
+	// inherit document font style for watermark. Watermarks without a font size fit the page diagonal
 	doc.Watermark.TextStyle.Copy(&doc.TextStyle)
+	doc.Watermark.TextStyle.FontSize = types.Dimension{}
+	doc.Watermark.Angle = 45
 
-	if err := attrWalker("document.watermark", doc.Watermark.Attributes, doc, nil, true); err != nil {
+	watermarkAttributes, conditionalStyles, err := combineAttributes(doc, doc.Watermark.StyleList, doc.Watermark.Attributes)
+	if err != nil {
 		return errors.Wrapf(err, "error in document watermark")
 	}
-
-	// initialize header and footer
-	doc.Head.Inherit(&doc.Element)
-	doc.Foot.Inherit(&doc.Element)
-
-	if err := attrWalker("header", doc.Head.Attrs, &doc.Head, doc); err != nil {
-		return errors.Wrapf(err, "error in header")
+	if len(conditionalStyles) > 0 {
+		return errors.New("error in document watermark: conditional styles are only supported on cells")
+	}
+	if err := applyAttributes("document.watermark", watermarkAttributes, doc, nil, true); err != nil {
+		return errors.Wrapf(err, "error in document watermark")
+	}
+	if doc.Watermark.Fit == nil {
+		fit := doc.Watermark.TextStyle.FontSize.Value == 0
+		doc.Watermark.Fit = &fit
+	}
+	if doc.Watermark.TextStyle.FontSize.Value == 0 {
+		doc.Watermark.TextStyle.FontSize = doc.TextStyle.FontSize
+	}
+	if doc.Watermark.Image != "" {
+		if doc.Watermark.ImageData, err = doc.ResolveImage(doc.Watermark.Image); err != nil {
+			return errors.Wrap(err, "error in document watermark: image")
+		}
 	}
 
-	// header cells
-	for i, hc := range doc.Head.Cells {
-		hc.Inherit(&doc.Head.Element)
-		if err := attrWalker("cell", hc.Attrs, hc, &doc.Head); err != nil {
-			return errors.Wrapf(err, "error in header cell %d", i)
+	// header and footer sections. Variants inherit from the default section
+	sectionWalker := func(prefix, name string, section types.IElement, area *types.SectionArea, cells []*types.Cell, parent types.IElement, defaultArea *types.SectionArea) error {
+		el := section.GetElement()
+		el.Inherit(parent.GetElement())
+		if err := attrWalker(prefix, el.Attrs, section, parent); err != nil {
+			return errors.Wrapf(err, "error in %s", name)
 		}
+		if defaultArea != nil && area.Height == nil {
+			area.Height = defaultArea.Height
+		}
+		if defaultArea != nil {
+			for _, side := range []struct{ dst, src **types.Dimension }{
+				{&area.Margins.Left, &defaultArea.Margins.Left},
+				{&area.Margins.Top, &defaultArea.Margins.Top},
+				{&area.Margins.Right, &defaultArea.Margins.Right},
+				{&area.Margins.Bottom, &defaultArea.Margins.Bottom},
+			} {
+				if *side.dst == nil {
+					*side.dst = *side.src
+				}
+			}
+		}
+		for i, sc := range cells {
+			sc.Inherit(el)
+			if err := attrWalker("cell", sc.Attrs, sc, section); err != nil {
+				return errors.Wrapf(err, "error in %s cell %d", name, i)
+			}
+			if err := childWalker(sc); err != nil {
+				return errors.Wrapf(err, "error in %s cell %d", name, i)
+			}
+			if hasBookmark(sc) {
+				return errors.Errorf("error in %s cell %d: bookmarks are not supported in headers and footers", name, i)
+			}
+		}
+		return nil
+	}
+	variantCheck := func(name string, variant types.SectionVariant, nested int) error {
+		if err := variant.Parse(string(variant)); err != nil || variant == types.SV_DEFAULT {
+			return errors.Errorf("error in %s: invalid variant `%s`, expected first, odd, even or last", name, variant)
+		}
+		if nested > 0 {
+			return errors.Errorf("error in `%s` %s: variants cannot be nested", variant, name)
+		}
+		return nil
 	}
 
-	if err := attrWalker("footer", doc.Foot.Attrs, &doc.Foot, doc); err != nil {
-		return errors.Wrapf(err, "error in footer")
+	headerWalker := func(head *types.Header, parent types.IElement, name string) error {
+		if err := sectionWalker("header", name, head, &head.SectionArea, head.Cells, parent, nil); err != nil {
+			return err
+		}
+		for variant, vh := range head.Variants {
+			if err := variantCheck(name, variant, len(vh.Variants)); err != nil {
+				return err
+			}
+			if err := sectionWalker("header", fmt.Sprintf("`%s` %s", variant, name), vh, &vh.SectionArea, vh.Cells, head, &head.SectionArea); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	for i, fc := range doc.Foot.Cells {
-		fc.Inherit(&doc.Foot.Element)
-		if err := attrWalker("cell", fc.Attrs, fc, &doc.Foot); err != nil {
-			return errors.Wrapf(err, "error in footer cell %d", i)
+	footerWalker := func(foot *types.Footer, parent types.IElement, name string) error {
+		if err := sectionWalker("footer", name, foot, &foot.SectionArea, foot.Cells, parent, nil); err != nil {
+			return err
+		}
+		for variant, vf := range foot.Variants {
+			if err := variantCheck(name, variant, len(vf.Variants)); err != nil {
+				return err
+			}
+			if err := sectionWalker("footer", fmt.Sprintf("`%s` %s", variant, name), vf, &vf.SectionArea, vf.Cells, foot, &foot.SectionArea); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+
+	if err := headerWalker(&doc.Head, doc, "header"); err != nil {
+		return err
+	}
+	if err := footerWalker(&doc.Foot, doc, "footer"); err != nil {
+		return err
 	}
 
-	for i, page := range doc.Pages {
+	pageIndex := 0
+	pageWalker := func(page *types.Page, parent types.IElement) error {
+		i := pageIndex
 		page.PageIndex = i
+		pageIndex++
 
-		// page defaults (inherit from document)
-		page.Inherit(&doc.Element)
+		// page defaults (inherit from document or section)
+		page.Inherit(parent.GetElement())
 
 		// page attributes
-		if err := attrWalker("page", page.Attrs, page, doc); err != nil {
+		if err := attrWalker("page", page.Attrs, page, parent); err != nil {
 			return errors.Wrapf(err, "error in page %d", i)
 		}
 
@@ -169,7 +355,139 @@ func validateDocument(doc *types.Document) error {
 			if err := attrWalker("cell", cell.Attrs, cell, page); err != nil {
 				return errors.Wrapf(err, "error in cell %d of page %d", ic, i)
 			}
+			if err := childWalker(cell); err != nil {
+				return errors.Wrapf(err, "error in cell %d of page %d", ic, i)
+			}
+		}
+		return nil
+	}
+
+	for _, page := range doc.Pages {
+		if err := pageWalker(page, doc); err != nil {
+			return err
+		}
+	}
+
+	// sections inherit from the document and hold their own pages
+	for i, section := range doc.Sections {
+		if len(section.Pages) == 0 {
+			return errors.Errorf("error in section %d: no page data provided", i)
+		}
+		if section.Orientation != "" {
+			if err := section.Orientation.Parse(string(section.Orientation)); err != nil {
+				return errors.Wrapf(err, "error in section %d", i)
+			}
+		}
+		if section.PageSize != "" {
+			if err := section.PageSize.Validate(); err != nil {
+				return errors.Wrapf(err, "error in section %d", i)
+			}
+		}
+		section.Inherit(&doc.Element)
+		if err := attrWalker("section", section.Attrs, section, doc); err != nil {
+			return errors.Wrapf(err, "error in section %d", i)
+		}
+		if section.Head != nil {
+			if err := headerWalker(section.Head, section, fmt.Sprintf("header of section %d", i)); err != nil {
+				return err
+			}
+		}
+		if section.Foot != nil {
+			if err := footerWalker(section.Foot, section, fmt.Sprintf("footer of section %d", i)); err != nil {
+				return err
+			}
+		}
+		for _, page := range section.Pages {
+			page.Section = section
+			if err := pageWalker(page, section); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
+
+var conditionalStyleRegex = regexp.MustCompile(`^\s*(\S+)\s+if\s+(.+)$`)
+
+// Combines style list and inline attributes, in order of (defined list, inline list) to allow overriding.
+// Style list entries of the form `style-name if condition` are returned separately as they are resolved at render time.
+func combineAttributes(doc *types.Document, styles []string, attrs []*types.Attribute) ([]*types.Attribute, []*types.ConditionalStyle, error) {
+	combinedAttributes := []*types.Attribute{}
+	var conditionalStyles []*types.ConditionalStyle
+	for _, styleName := range styles {
+		condition := ""
+		if m := conditionalStyleRegex.FindStringSubmatch(styleName); m != nil {
+			styleName, condition = m[1], m[2]
+			if _, err := expr.Compile(condition); err != nil {
+				return nil, nil, errors.Wrapf(err, "`%s`: invalid style condition", styleName)
+			}
+		}
+		style, ok := doc.GetStyleByName(styleName)
+		if !ok {
+			return nil, nil, errors.Errorf("`%s`: style does not exist", styleName)
+		}
+		if condition != "" {
+			conditionalStyles = append(conditionalStyles, &types.ConditionalStyle{Style: style, Condition: condition})
+		} else {
+			combinedAttributes = append(combinedAttributes, style.Attributes...)
+		}
+	}
+	combinedAttributes = append(combinedAttributes, attrs...)
+	return combinedAttributes, conditionalStyles, nil
+}
+
+// Gives the span the font of its cell. Spans are drawn with the font of their cell applied, so their size is relative to it
+func inheritSpan(span *types.Span, cell *types.Cell) {
+	span.Inherit(&cell.Element)
+	span.TextStyle.FontSize = *types.MustParseDimension("100%")
+}
+
+// Applies attributes using the generic handlers and the handlers specific to the prefix (e.g. `cell.width`)
+func applyAttributes(prefix string, attrs []*types.Attribute, e types.IElement, parent types.IElement, specialHandlerOnly bool) error {
+	if prefix != "" {
+		prefix = prefix + "."
+	}
+	for _, attr := range attrs {
+		var genOk bool
+		var genericHandler AttributeHandler
+
+		if !specialHandlerOnly {
+			genericHandler, genOk = attributeHandlers[attr.Name]
+		}
+
+		specialHandler, specOk := attributeHandlers[prefix+attr.Name]
+		if genOk {
+			if err := genericHandler(e, parent, attr.Value); err != nil {
+				return errors.Wrapf(err, "attribute `%s`", attr.Name)
+			}
+		}
+		if specOk {
+			if err := specialHandler(e, parent, attr.Value); err != nil {
+				return errors.Wrapf(err, "attribute `%s`", attr.Name)
+			}
+		}
+		if !genOk && !specOk {
+			return errors.Errorf("unsupported attribute `%s`", attr.Name)
+		}
+	}
+	return nil
+}
+
+// Reports whether the cell, its child cells or the cells of its table have a bookmark
+func hasBookmark(cell *types.Cell) bool {
+	if cell.BookmarkTitle != "" {
+		return true
+	}
+	cells := cell.Cells
+	if table := cell.Table; table != nil {
+		for _, row := range append(append([]*types.TableRow{}, table.Header...), table.Rows...) {
+			cells = append(cells, row.Cells...)
+		}
+	}
+	for _, child := range cells {
+		if hasBookmark(child) {
+			return true
+		}
+	}
+	return false
+}
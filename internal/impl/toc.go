@@ -0,0 +1,29 @@
+package impl
+
+import "github.com/gintec-rdl/pdf-go/pkg/types"
+
+type tocImpl struct {
+	elementImpl[types.PdfTemplateToc]
+	parent types.PdfTemplatePage
+	toc    *types.Toc
+}
+
+func (p *pageImpl) AddToc() types.PdfTemplateToc {
+	var toc types.Toc
+	p.page.Cells = append(p.page.Cells, &types.Cell{Toc: &toc})
+	t := &tocImpl{parent: p, toc: &toc}
+	t.self = t
+	t.container.builder = p.container.builder
+	t.container.attributes = &toc.Attrs
+	return t
+}
+
+func (t *tocImpl) Parent() types.PdfTemplatePage {
+	return t.parent
+}
+
+func (t *tocImpl) StyleList(name string, more ...string) types.PdfTemplateToc {
+	t.toc.StyleList = append(t.toc.StyleList, name)
+	t.toc.StyleList = append(t.toc.StyleList, more...)
+	return t
+}
@@ -0,0 +1,164 @@
+package impl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gintec-rdl/pdf-go/internal/expr"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Bookmarked page or cell, listed in the tables of contents
+type tocEntry struct {
+	title  string
+	level  int     // nesting level, from 0
+	pageNo int     // physical page of the destination, starting at 1
+	page   int     // displayed number of the page
+	y      float64 // position of the destination on the page
+	anchor string  // anchor the entry points to in place of its position, found once all pages are rendered
+}
+
+// Lists an outline entry at the position y on the current page, or at the anchor when set, for the outline and the
+// tables of contents. Levels start at 1 and nest at most one level below the previous entry.
+func (r *renderer) bookmark(title, anchor string, level int, ctx *cellContext, y float64) {
+	level = min(level, r.level+1)
+	r.level = level
+	r.entries = append(r.entries, tocEntry{title: title, level: level - 1, pageNo: ctx.pageNo, page: r.pageNumber(ctx.pageNo), y: y, anchor: anchor})
+}
+
+// Moves the entries pointing to an anchor to its page and position, then adds the entries to the outline of the
+// document on their page. Called once all pages are rendered, when every anchor is set.
+func (r *renderer) outline() error {
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if entry.anchor != "" {
+			pageNo, y, ok := r.pdfDoc.GetAnchor(entry.anchor)
+			if !ok {
+				return errors.Errorf("bookmark `%s`: anchor `%s` is not defined", entry.title, entry.anchor)
+			}
+			entry.pageNo, entry.page, entry.y = pageNo, r.pageNumber(pageNo), y
+		}
+		r.pdfDoc.GetPage(entry.pageNo)
+		r.pdfDoc.SetBookmark(entry.title, entry.level, entry.y)
+	}
+	// the footer of the last page is rendered when the document is closed
+	r.pdfDoc.GetPage(r.pdfDoc.GetPageCount())
+	return nil
+}
+
+// Returns the anchor the bookmark of the element points to, empty for its own position
+func (r *renderer) bookmarkTo(el *types.Element, scope *expr.Scope) (string, error) {
+	anchor, err := r.resolveText(el.BookmarkTo, scope)
+	if err != nil {
+		return "", errors.Wrap(err, "bookmark-to")
+	}
+	return anchor, nil
+}
+
+// Returns the outline level of the section bookmark
+func sectionLevel(section *types.Section) int {
+	if section.BookmarkLevel > 0 {
+		return section.BookmarkLevel
+	}
+	return 1
+}
+
+// Returns the outline level of the page bookmark, below the bookmark of its section when the section has a title
+func pageLevel(page *types.Page) int {
+	if page.BookmarkLevel > 0 {
+		return page.BookmarkLevel
+	}
+	if section := page.Section; section != nil && section.BookmarkTitle != "" {
+		return sectionLevel(section) + 1
+	}
+	return 1
+}
+
+// Bookmarks cells with a bookmark title at the position y, wherever they are drawn on the page. Cells are nested below the page bookmark when page
+// bookmarks are shown, and take its level otherwise, unless they set their own level.
+func (r *renderer) bookmarkCell(cell *types.Cell, ctx *cellContext, scope *expr.Scope, y float64) error {
+	if cell.BookmarkTitle == "" {
+		return nil
+	}
+	title, err := r.resolveText(cell.BookmarkTitle, scope)
+	if err != nil {
+		return errors.Wrap(err, "bookmark")
+	}
+	anchor, err := r.bookmarkTo(&cell.Element, scope)
+	if err != nil {
+		return err
+	}
+	level := cell.BookmarkLevel
+	if level == 0 {
+		level = pageLevel(ctx.page)
+		if r.doc.PageBookmarks {
+			level++
+		}
+	}
+	r.bookmark(title, anchor, level, ctx, y)
+	return nil
+}
+
+// Draws one line per entry found by the layout pass: the title indented by its level, leaders and the page number.
+// Lines link to their entry and continue on a new page when they do not fit, then the cursor moves to the next row.
+func (r *renderer) renderToc(c types.Canvas, host *types.Cell, ctx *cellContext) error {
+	r.tocs++
+	toc := host.Toc
+	brush := toc.TextStyle
+	brush.DisplayStyle = types.DISPLAY_COLUMN
+	brush.Alignment = "LM"
+	brush.Wrap = false
+
+	if ctx.beforeCell != nil {
+		ctx.beforeCell()
+	}
+	indentSize := types.NewDimension(5, types.DU_MILIMETER)
+	if toc.Indent != nil {
+		indentSize = toc.Indent
+	}
+	indent := indentSize.GetValue(c.GetDrawingRect().Right, 0, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+	margins, _ := c.MeasureText("", &brush) // the cell margins on both sides of a text
+	leaderW, _ := c.MeasureText(toc.Leader, &brush)
+	leaderW -= margins
+
+	x := c.GetX()
+	for _, entry := range r.toc {
+		dc := c.GetDrawingRect()
+		lineW := dc.Left + dc.Right - x
+		if host.Width != nil {
+			lineW = host.Width.GetValue(dc.Right, 0, 0, types.UT_LENGTH|types.UT_LENGH_WIDTH, r.doc.DisplayUnit)
+		}
+		number := strconv.Itoa(entry.page)
+		titleW, h := c.MeasureText(entry.title, &brush)
+		numberW, _ := c.MeasureText(number, &brush)
+		if pageNo := ctx.pageNo; ctx.isPageCell {
+			if err := r.ensureSpace(c, ctx, h); err != nil {
+				return err
+			}
+			if ctx.pageNo != pageNo {
+				x = c.GetX()
+			}
+		}
+		y := c.GetY()
+		left := x + indent*float64(entry.level)
+		gap := lineW - (left - x) - titleW - numberW
+		if gap < 0 {
+			titleW, gap = max(titleW+gap, 0), 0
+		}
+
+		c.SetXY(left, y)
+		c.DrawText(titleW, h, entry.title, &brush)
+		if n := int((gap - margins/2) / leaderW); n > 0 {
+			leaders := brush
+			leaders.Alignment = "RM"
+			c.DrawText(gap, h, strings.Repeat(toc.Leader, n), &leaders)
+		}
+		c.SetXY(x+lineW-numberW, y)
+		c.DrawText(numberW, h, number, &brush)
+		c.LinkPage(types.Rect{Left: left, Top: y, Right: x + lineW - left, Bottom: h}, entry.pageNo, entry.y)
+		c.SetXY(x, y+h)
+	}
+	c.SetXY(c.GetDrawingRect().Left, c.GetY())
+	return nil
+}
@@ -0,0 +1,239 @@
+package impl_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the `title number` lines of the tables of contents drawn on the page, found around their leaders
+func tocLines(texts []string) []string {
+	lines := []string{}
+	for i := 1; i < len(texts)-1; i++ {
+		if strings.Trim(texts[i], ".") == "" {
+			lines = append(lines, texts[i-1]+" "+texts[i+1])
+		}
+	}
+	return lines
+}
+
+func TestToc(t *testing.T) {
+	chapters := func(p types.PdfTemplatePage, height string) {
+		p.Attribute("flow", "true").AddRepeat("chapters").
+			AddCell().Text("${item}").Attributes(types.PdfTemplateAttributes{"bookmark": "${item}", "anchor": "chapter-${index}", "display": "row"}).Parent().
+			AddCell().Text("Body").Attributes(types.PdfTemplateAttributes{"height": height, "display": "row"})
+	}
+	tests := []struct {
+		name     string
+		chapters int
+		build    func(b types.PdfTemplateBuilder)
+		want     [][]string // first and last lines of the tables of contents on every page
+		links    []string   // links of the first page
+	}{
+		{
+			name:     "pages and cells",
+			chapters: 4,
+			build: func(b types.PdfTemplateBuilder) {
+				b.ShowBookmarks(true)
+				b.AddPage().BookmarkTitle("Contents").AddToc()
+				chapters(b.AddPage().BookmarkTitle("Chapters"), "100mm")
+			},
+			want:  [][]string{{"Contents 1", "Chapter 4 3"}, nil, nil},
+			links: []string{"page 1", "page 2", "page 2", "page 2", "page 2", "page 3"},
+		},
+		{
+			name:     "continued on the next page",
+			chapters: 70,
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddToc()
+				chapters(b.AddPage(), "5mm")
+			},
+			want: [][]string{{"Chapter 1 3", "Chapter 63 5"}, {"Chapter 64 5", "Chapter 70 5"}, nil, nil, nil},
+		},
+		{
+			name:     "section page numbers",
+			chapters: 1,
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddToc()
+				chapters(b.AddSection().Attribute("page-number", "10").AddPage(), "5mm")
+			},
+			want: [][]string{{"Chapter 1 10", "Chapter 1 10"}, nil},
+		},
+		{
+			name:     "bookmark to anchor",
+			chapters: 3,
+			build: func(b types.PdfTemplateBuilder) {
+				p := b.AddPage()
+				p.AddToc()
+				p.AddCell().Text("Last").Attributes(types.PdfTemplateAttributes{"bookmark": "Last chapter", "bookmark-to": "chapter-2"})
+				chapters(b.AddPage(), "150mm")
+			},
+			want:  [][]string{{"Last chapter 3", "Chapter 3 3"}, nil, nil, nil},
+			links: []string{"page 3", "page 2", "page 2", "page 3"},
+		},
+		{
+			name:     "headings in containers",
+			chapters: 3,
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddToc()
+				b.AddPage().AddCell().Attributes(types.PdfTemplateAttributes{"layout": "flex", "flex-direction": "column", "display": "row"}).
+					AddCell().Repeat("chapters").Text("${item}").Attributes(types.PdfTemplateAttributes{"bookmark": "${item}", "height": "8mm"})
+			},
+			want:  [][]string{{"Chapter 1 2", "Chapter 3 2"}, nil},
+			links: []string{"page 2", "page 2", "page 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]string, tt.chapters)
+			for i := range data {
+				data[i] = fmt.Sprintf("Chapter %d", i+1)
+			}
+			b := newBuilder()
+			tt.build(b)
+			rec, err := render(t, b, map[string]any{"chapters": data})
+			assert.Nil(t, err)
+			got := [][]string{}
+			for _, page := range rec.pages() {
+				if lines := tocLines(page); len(lines) > 0 {
+					got = append(got, []string{lines[0], lines[len(lines)-1]})
+				} else {
+					got = append(got, nil)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+			if tt.links != nil {
+				assert.Equal(t, tt.links, rec.links[1])
+			}
+		})
+	}
+}
+
+func TestOutline(t *testing.T) {
+	sections := []map[string]any{
+		{"title": "Background", "subsections": []string{"History", "Scope"}},
+		{"title": "Findings", "subsections": []string{"Costs"}},
+	}
+	content := func(p types.PdfTemplatePage, subsection types.PdfTemplateAttributes) {
+		p.Attribute("flow", "true").AddRepeat("sections").
+			AddCell().Text("${item.title}").Attributes(types.PdfTemplateAttributes{"bookmark": "${item.title}", "display": "row"}).Parent().
+			AddCell().Repeat("item.subsections").Text("${item}").Attributes(types.PdfTemplateAttributes{"bookmark": "${item}", "height": "100mm", "display": "row"}).Attributes(subsection)
+	}
+	tests := []struct {
+		name  string
+		build func(b types.PdfTemplateBuilder)
+		want  []string
+		err   string
+	}{
+		{
+			name: "sections, pages and cells",
+			build: func(b types.PdfTemplateBuilder) {
+				b.ShowBookmarks(true)
+				b.AddPage().BookmarkTitle("Contents")
+				content(b.AddSection().BookmarkTitle("Chapter").PageBookmarkTemplate("Page ${page}").AddPage(), types.PdfTemplateAttributes{"bookmark-level": "4"})
+			},
+			want: []string{"0 Contents @1", "0 Chapter @2", "1 Page 2 @2", "2 Background @2", "3 History @2", "3 Scope @2", "2 Findings @2", "3 Costs @3"},
+		},
+		{
+			name: "without page bookmarks",
+			build: func(b types.PdfTemplateBuilder) {
+				b.AddPage().AddCell().Text("Cover")
+				content(b.AddPage(), nil)
+			},
+			want: []string{"0 Background @2", "0 History @2", "0 Scope @2", "0 Findings @2", "0 Costs @3"},
+		},
+		{
+			name: "levels",
+			build: func(b types.PdfTemplateBuilder) {
+				b.ShowBookmarks(true)
+				b.AddPage().BookmarkTitle("Contents")
+				content(b.AddSection().BookmarkTitle("Chapter").Attribute("bookmark-level", "2").AddPage().BookmarkTitle("Body").Attribute("bookmark-level", "1"), nil)
+			},
+			want: []string{"0 Contents @1", "1 Chapter @2", "0 Body @2", "1 Background @2", "1 History @2", "1 Scope @2", "1 Findings @2", "1 Costs @3"},
+		},
+		{
+			name: "in headers",
+			build: func(b types.PdfTemplateBuilder) {
+				b.Header().AddCell().Text("Report").Attribute("bookmark", "Report")
+				content(b.AddPage(), nil)
+			},
+			err: "error in header cell 0: bookmarks are not supported in headers and footers",
+		},
+		{
+			name: "invalid level",
+			build: func(b types.PdfTemplateBuilder) {
+				content(b.AddPage(), types.PdfTemplateAttributes{"bookmark-level": "0"})
+			},
+			err: "error in cell 0 of page 0: child cell 1: attribute `bookmark-level`: invalid bookmark level `0`, expected a number from 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b)
+			rec, err := render(t, b, map[string]any{"sections": sections})
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.bookmarks)
+		})
+	}
+}
+
+func TestBookmarkPositions(t *testing.T) {
+	rows := make([]int, 40)
+	tests := []struct {
+		name  string
+		build func(p types.PdfTemplatePage)
+		want  []string
+		marks []float64
+	}{
+		{
+			name: "flex container",
+			build: func(p types.PdfTemplatePage) {
+				p.AddCell().Text("Cover").Attributes(types.PdfTemplateAttributes{"height": "20mm", "display": "row"})
+				flex := p.AddCell().Attributes(types.PdfTemplateAttributes{"layout": "flex", "padding": "5mm", "display": "row"})
+				flex.AddCell().Text("Summary").Attributes(types.PdfTemplateAttributes{"bookmark": "Summary", "width": "50mm", "height": "8mm"})
+				flex.AddCell().Text("Details").Attributes(types.PdfTemplateAttributes{"bookmark": "Details", "width": "50mm", "height": "8mm", "margin-top": "2mm"})
+			},
+			want:  []string{"0 Summary @1", "0 Details @1"},
+			marks: []float64{35, 37},
+		},
+		{
+			name: "table rows",
+			build: func(p types.PdfTemplatePage) {
+				p.AddCell().Text("Cover").Attributes(types.PdfTemplateAttributes{"height": "20mm", "display": "row"})
+				table := p.AddTable().Columns("auto")
+				table.AddRow().Attribute("height", "10mm").AddCell().Text("Items")
+				table.AddRow().Attribute("height", "10mm").AddCell().Text("Totals").Attribute("bookmark", "Totals")
+			},
+			want:  []string{"0 Totals @1"},
+			marks: []float64{40},
+		},
+		{
+			name: "repeated header rows",
+			build: func(p types.PdfTemplatePage) {
+				table := p.AddTable().Columns("auto")
+				table.AddHeaderRow().Attribute("height", "10mm").AddCell().Text("Entries").Attribute("bookmark", "Entries")
+				table.AddRow().Repeat("rows").Attribute("height", "10mm").AddCell().Text("${index}")
+			},
+			want:  []string{"0 Entries @1"},
+			marks: []float64{10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			tt.build(b.AddPage())
+			rec, err := render(t, b, map[string]any{"rows": rows})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, rec.bookmarks)
+			assert.InDeltaSlice(t, tt.marks, rec.marks, .01)
+		})
+	}
+}
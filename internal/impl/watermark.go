@@ -1,6 +1,10 @@
 package impl
 
-import "github.com/gintec-rdl/pdf-go/pkg/types"
+import (
+	"strconv"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+)
 
 type watermarkImpl struct {
 	doc       *types.Document
@@ -17,6 +21,31 @@ func (w *watermarkImpl) Attributes(attrs types.PdfTemplateAttributes) types.PdfT
 	return w
 }
 
+func (w *watermarkImpl) Image(source string) types.PdfTemplateWatermark {
+	return w.Attribute("image", source)
+}
+
+func (w *watermarkImpl) Size(width, height string) types.PdfTemplateWatermark {
+	if width != "" {
+		w.Attribute("width", width)
+	}
+	if height != "" {
+		w.Attribute("height", height)
+	}
+	return w
+}
+
+func (w *watermarkImpl) Placement(position string, angle float64) types.PdfTemplateWatermark {
+	return w.Attributes(types.PdfTemplateAttributes{
+		"position": position,
+		"angle":    strconv.FormatFloat(angle, 'f', -1, 64),
+	})
+}
+
+func (w *watermarkImpl) If(condition string) types.PdfTemplateWatermark {
+	return w.Attribute("if", condition)
+}
+
 func (w *watermarkImpl) StyleList(name string, more ...string) types.PdfTemplateWatermark {
 	if w.doc.Watermark.StyleList == nil {
 		w.doc.Watermark.StyleList = make([]string, 0)
@@ -0,0 +1,257 @@
+// Package markdown parses the subset of Markdown rendered by markdown cells: paragraphs, headings, bullet and
+// numbered lists, emphasis, strong emphasis, inline code and links.
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type BlockKind int
+
+const (
+	Paragraph BlockKind = iota
+	Heading
+	BulletItem
+	NumberedItem
+)
+
+// Paragraph, heading or list item
+type Block struct {
+	Kind    BlockKind
+	Level   int // level of headings from 1 to 6, nesting depth of list items from 0
+	Number  int // number of numbered list items
+	Inlines []Inline
+}
+
+// Run of text with its inline formatting
+type Inline struct {
+	Text     string
+	Emphasis bool
+	Strong   bool
+	Code     bool
+	Link     string // target of links
+}
+
+var (
+	headingRegex  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	bulletRegex   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedRegex = regexp.MustCompile(`^(\s*)(\d{1,9})[.)]\s+(.*)$`)
+)
+
+// Parses the blocks of the text. Lines following a paragraph or a list item continue it, unless they start
+// a new block, and lines ending with two spaces or a backslash break the line.
+func Parse(text string) []Block {
+	blocks := []Block{}
+	var current *Block
+	var lines []string
+	flush := func() {
+		if current != nil {
+			current.Inlines = ParseInline(strings.Join(lines, "\n"))
+			blocks = append(blocks, *current)
+		}
+		current, lines = nil, nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			current, lines = &Block{Kind: Heading, Level: len(m[1])}, []string{m[2]}
+			flush()
+			continue
+		}
+		if m := bulletRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			current, lines = &Block{Kind: BulletItem, Level: depth(m[1])}, []string{m[2]}
+			continue
+		}
+		if m := numberedRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			number, _ := strconv.Atoi(m[2])
+			current, lines = &Block{Kind: NumberedItem, Level: depth(m[1]), Number: number}, []string{m[3]}
+			continue
+		}
+		if current == nil {
+			current = &Block{Kind: Paragraph}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return blocks
+}
+
+// Returns the nesting depth of a list item from its indentation, two spaces or a tab per level
+func depth(indent string) int {
+	return len(strings.ReplaceAll(indent, "\t", "  ")) / 2
+}
+
+// Parses the inline formatting of the text. Lines are joined with a space, or a newline when they end with
+// two spaces or a backslash.
+func ParseInline(text string) []Inline {
+	var sb strings.Builder
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			sb.WriteString(strings.TrimSpace(line))
+			break
+		}
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		sb.WriteString(strings.TrimSpace(strings.TrimSuffix(line, "\\")))
+		if hardBreak {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(" ")
+		}
+	}
+	p := &inlineParser{}
+	p.parse([]rune(sb.String()), Inline{})
+	return p.inlines
+}
+
+type inlineParser struct {
+	inlines []Inline
+}
+
+// Appends text in the format of the inline, merging it with the previous inline of the same format
+func (p *inlineParser) add(text string, format Inline) {
+	if text == "" {
+		return
+	}
+	if n := len(p.inlines); n > 0 {
+		last := &p.inlines[n-1]
+		if last.Emphasis == format.Emphasis && last.Strong == format.Strong && last.Code == format.Code && last.Link == format.Link {
+			last.Text += text
+			return
+		}
+	}
+	format.Text = text
+	p.inlines = append(p.inlines, format)
+}
+
+func (p *inlineParser) parse(text []rune, format Inline) {
+	start := 0
+	literal := func(i int) {
+		p.add(string(text[start:i]), format)
+	}
+	for i := 0; i < len(text); i++ {
+		switch r := text[i]; {
+		case r == '\\' && i+1 < len(text) && unicode.IsPunct(text[i+1]):
+			literal(i)
+			start = i + 1
+			i++
+		case r == '`':
+			end := index(text, i+1, "`")
+			if end < 0 {
+				continue
+			}
+			literal(i)
+			code := format
+			code.Code = true
+			p.add(string(text[i+1:end]), code)
+			start, i = end+1, end
+		case r == '[':
+			label, target, end, ok := link(text, i)
+			if !ok {
+				continue
+			}
+			literal(i)
+			linked := format
+			linked.Link = target
+			p.parse(label, linked)
+			start, i = end, end-1
+		case r == '*' || r == '_':
+			n := 1
+			for i+n < len(text) && text[i+n] == r && n < 3 {
+				n++
+			}
+			delimiter := strings.Repeat(string(r), n)
+			end := closing(text, i+n, delimiter)
+			if end < 0 || (r == '_' && i > 0 && isWordRune(text[i-1])) {
+				i += n - 1
+				continue
+			}
+			literal(i)
+			inner := format
+			inner.Emphasis = inner.Emphasis || n != 2
+			inner.Strong = inner.Strong || n >= 2
+			p.parse(text[i+n:end], inner)
+			start, i = end+n, end+n-1
+		}
+	}
+	literal(len(text))
+}
+
+// Returns the index of the first occurrence of s in text from the index from, -1 if none
+func index(text []rune, from int, s string) int {
+	if i := strings.Index(string(text[from:]), s); i >= 0 {
+		return from + len([]rune(string(text[from:])[:i]))
+	}
+	return -1
+}
+
+// Returns the index of the delimiter closing an emphasis opened right before from, -1 if none. The emphasis
+// cannot start or end with whitespace.
+func closing(text []rune, from int, delimiter string) int {
+	if from >= len(text) || unicode.IsSpace(text[from]) {
+		return -1
+	}
+	n := len(delimiter)
+	for i := from + 1; i+n <= len(text); i++ {
+		if string(text[i:i+n]) != delimiter || unicode.IsSpace(text[i-1]) {
+			continue
+		}
+		// the delimiter must not be part of a longer run
+		if i+n < len(text) && text[i+n] == text[i] {
+			i += n
+			for i < len(text) && text[i] == text[i-1] {
+				i++
+			}
+			continue
+		}
+		if delimiter[0] == '_' && i+n < len(text) && isWordRune(text[i+n]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// Parses a link `[label](target)` starting at the index i. end is the index following the link.
+func link(text []rune, i int) (label []rune, target string, end int, ok bool) {
+	depth := 0
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if j+1 >= len(text) || text[j+1] != '(' {
+				return nil, "", 0, false
+			}
+			paren := index(text, j+2, ")")
+			if paren < 0 {
+				return nil, "", 0, false
+			}
+			target = strings.TrimSpace(string(text[j+2 : paren]))
+			if target == "" || strings.ContainsAny(target, " \t\n") {
+				return nil, "", 0, false
+			}
+			return text[i+1 : j], target, paren + 1, true
+		}
+	}
+	return nil, "", 0, false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
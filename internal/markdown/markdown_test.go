@@ -0,0 +1,33 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/markdown"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	blocks := markdown.Parse("# Terms\n\nPayment is due\nwithin *30 days*.\n\n- first\n  - nested\n2. second")
+	assert.Equal(t, []markdown.Block{
+		{Kind: markdown.Heading, Level: 1, Inlines: []markdown.Inline{{Text: "Terms"}}},
+		{Kind: markdown.Paragraph, Inlines: []markdown.Inline{{Text: "Payment is due within "}, {Text: "30 days", Emphasis: true}, {Text: "."}}},
+		{Kind: markdown.BulletItem, Inlines: []markdown.Inline{{Text: "first"}}},
+		{Kind: markdown.BulletItem, Level: 1, Inlines: []markdown.Inline{{Text: "nested"}}},
+		{Kind: markdown.NumberedItem, Number: 2, Inlines: []markdown.Inline{{Text: "second"}}},
+	}, blocks)
+}
+
+func TestParseInline(t *testing.T) {
+	assert.Equal(t, []markdown.Inline{
+		{Text: "a "},
+		{Text: "b ", Strong: true},
+		{Text: "c", Strong: true, Emphasis: true},
+		{Text: " "},
+		{Text: "x := 1", Code: true},
+		{Text: " see "},
+		{Text: "site", Link: "https://example.com"},
+	}, markdown.ParseInline("a **b _c_** `x := 1` see [site](https://example.com)"))
+	assert.Equal(t, []markdown.Inline{{Text: "snake_case_name, 2 * 3 * 4 and *literal"}}, markdown.ParseInline("snake_case_name, 2 * 3 * 4 and \\*literal"))
+	assert.Equal(t, []markdown.Inline{{Text: "line\nbreak"}}, markdown.ParseInline("line  \nbreak"))
+}
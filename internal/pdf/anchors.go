@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pkg/errors"
+)
+
+// Internal link destinations of a document by anchor name. Links can refer to anchors set further down the document.
+type anchorTable struct {
+	links map[string]int // gofpdf link of every anchor linked to or set
+	names []string       // anchors in the order they were first used
+	set   map[string]destination
+}
+
+// Page, starting at 1, and position of an anchor
+type destination struct {
+	page int
+	y    float64
+}
+
+// Returns the gofpdf link of the anchor, adding it on first use
+func (a *anchorTable) link(pdf *gofpdf.Fpdf, name string) int {
+	if a.links == nil {
+		a.links, a.set = map[string]int{}, map[string]destination{}
+	}
+	link, ok := a.links[name]
+	if !ok {
+		link = pdf.AddLink()
+		a.links[name] = link
+		a.names = append(a.names, name)
+	}
+	return link
+}
+
+// Sets the anchor at the position y on the current page. The first position set wins.
+func (a *anchorTable) setAnchor(pdf *gofpdf.Fpdf, name string, y float64) {
+	link := a.link(pdf, name)
+	if _, ok := a.set[name]; !ok {
+		a.set[name] = destination{page: pdf.PageNo(), y: y}
+		pdf.SetLink(link, y, -1)
+	}
+}
+
+// Returns the page and position of the anchor, ok is false when it is not set
+func (a *anchorTable) get(name string) (page int, y float64, ok bool) {
+	dest, ok := a.set[name]
+	return dest.page, dest.y, ok
+}
+
+// Returns an error for the first anchor linked to but never set
+func (a *anchorTable) check() error {
+	for _, name := range a.names {
+		if _, ok := a.set[name]; !ok {
+			return errors.Errorf("anchor `%s` is not defined", name)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 package pdf
 
 import (
+	"bytes"
+	"math"
+	"strings"
+
+	"github.com/gintec-rdl/pdf-go/internal/hyphen"
 	"github.com/gintec-rdl/pdf-go/pkg/types"
 	"github.com/jung-kurt/gofpdf"
 )
@@ -9,10 +14,29 @@ type PdfCanvas struct {
 	_pdf        *gofpdf.Fpdf
 	ctx         ContextStack
 	_parentUnit types.DimensionUnit
+	anchors     *anchorTable
 }
 
 func NewPdfCanvas(pdf *gofpdf.Fpdf, parentUnit types.DimensionUnit) types.Canvas {
-	return &PdfCanvas{_pdf: pdf, _parentUnit: parentUnit}
+	return &PdfCanvas{_pdf: pdf, _parentUnit: parentUnit, anchors: &anchorTable{}}
+}
+
+func (c *PdfCanvas) Link(rect types.Rect, target string) {
+	if name, ok := strings.CutPrefix(target, "#"); ok {
+		c._pdf.Link(rect.Left, rect.Top, rect.Right, rect.Bottom, c.anchors.link(c._pdf, name))
+		return
+	}
+	c._pdf.LinkString(rect.Left, rect.Top, rect.Right, rect.Bottom, target)
+}
+
+func (c *PdfCanvas) SetAnchor(name string, y float64) {
+	c.anchors.setAnchor(c._pdf, name, y)
+}
+
+func (c *PdfCanvas) LinkPage(rect types.Rect, page int, y float64) {
+	link := c._pdf.AddLink()
+	c._pdf.SetLink(link, y, page)
+	c._pdf.Link(rect.Left, rect.Top, rect.Right, rect.Bottom, link)
 }
 
 func (c *PdfCanvas) Save() {
@@ -84,10 +108,262 @@ func (c *PdfCanvas) ApplyTypingBrush(brush *types.TextBrush) {
 func (c *PdfCanvas) DrawText(w, h float64, text string, brush *types.TextBrush) {
 	c.Save()
 	c.ApplyTypingBrush(brush)
-	c._pdf.CellFormat(w, h, text, "", int(brush.DisplayStyle), brush.Alignment, false, 0, "")
+	if brush.Wrap {
+		c.drawWrappedText(w, h, text, brush)
+	} else {
+		c._pdf.CellFormat(w, h, text, "", int(brush.DisplayStyle), brush.Alignment, false, 0, "")
+	}
 	c.Restore()
 }
 
+// Draws the lines of the wrapped text in the box, aligned horizontally and vertically as a block.
+// Justified lines spread their words over the width, except the last line of each paragraph.
+// Lines that do not fit in the box are clipped.
+func (c *PdfCanvas) drawWrappedText(w, h float64, text string, brush *types.TextBrush) {
+	x, y := c._pdf.GetXY()
+	lines := c.wrapText(text, w, brush)
+	lineH := c.GetTextHeight()
+	blockH := lineH * float64(len(lines))
+	top, halign, justify := textBlock(brush.Alignment, h, blockH)
+
+	clip := blockH > h
+	if clip {
+		c._pdf.ClipRect(x, y, w, h, false)
+	}
+	for i, line := range lines {
+		lineY := y + top + lineH*float64(i)
+		if words := strings.Fields(line.Text); justify && !line.Last && len(words) > 1 {
+			c.drawJustifiedLine(x, lineY, w, lineH, words)
+			continue
+		}
+		c._pdf.SetXY(x, lineY)
+		c._pdf.CellFormat(w, lineH, line.Text, "", 0, halign+"M", false, 0, "")
+	}
+	if clip {
+		c._pdf.ClipEnd()
+	}
+	c.moveAfterText(x, y, w, h, brush.DisplayStyle)
+}
+
+// Returns the offset of a block of text of height blockH from the top of a box of height h, the horizontal
+// alignment of its lines and whether they are justified, from the alignment flags of a brush
+func textBlock(alignment string, h, blockH float64) (top float64, halign string, justify bool) {
+	align := strings.ToUpper(alignment)
+	top = (h - blockH) / 2
+	switch {
+	case strings.Contains(align, "T"):
+		top = 0
+	case strings.Contains(align, "B"):
+		top = h - blockH
+	}
+	halign = "L"
+	if i := strings.IndexAny(align, "LCR"); i >= 0 {
+		halign = align[i : i+1]
+	}
+	return top, halign, strings.Contains(align, "J")
+}
+
+// Moves the cursor past the box at x, y like a single cell of the display style would
+func (c *PdfCanvas) moveAfterText(x, y, w, h float64, display types.CellDisplay) {
+	switch display {
+	case types.DISPLAY_ROW:
+		left, _, _, _ := c._pdf.GetMargins()
+		c._pdf.SetXY(left, y+h)
+	case types.DISPLAY_STACK:
+		c._pdf.SetXY(x, y+h)
+	default:
+		c._pdf.SetXY(x+w, y)
+	}
+}
+
+// Draws the words at both edges of the line, spreading the space left between them
+func (c *PdfCanvas) drawJustifiedLine(x, y, w, h float64, words []string) {
+	margin := c._pdf.GetCellMargin()
+	widths, used := make([]float64, len(words)), 0.
+	for i, word := range words {
+		widths[i] = c._pdf.GetStringWidth(word)
+		used += widths[i]
+	}
+	gap := (w - 2*margin - used) / float64(len(words)-1)
+	wordX := x
+	for i, word := range words {
+		c._pdf.SetXY(wordX, y)
+		c._pdf.CellFormat(widths[i]+2*margin, h, word, "", 0, "LM", false, 0, "")
+		wordX += widths[i] + gap
+	}
+}
+
+// Returns the lines of the text wrapped to the width w with the current font, cell padding excluded.
+// Words are hyphenated in the language of the brush when it hyphenates.
+func (c *PdfCanvas) wrapText(text string, w float64, brush *types.TextBrush) []TextLine {
+	return WrapText(text, w-2*c._pdf.GetCellMargin(), c._pdf.GetStringWidth, c.hyphenation(brush))
+}
+
+// Returns the hyphenation points of words in the language of the brush, nil when it does not hyphenate
+func (c *PdfCanvas) hyphenation(brush *types.TextBrush) func(word string) []int {
+	if !brush.Hyphenate {
+		return nil
+	}
+	h, err := hyphen.Get(brush.Language)
+	if err != nil {
+		return nil
+	}
+	return h.Points
+}
+
+func (c *PdfCanvas) DrawRichText(w, h float64, paragraphs []types.TextParagraph, brush *types.TextBrush) {
+	x, y := c._pdf.GetXY()
+	c.Save()
+	c.ApplyTypingBrush(brush)
+	lines, blockH := c.layoutParagraphs(paragraphs, w, brush)
+	top, halign, justify := textBlock(brush.Alignment, h, blockH)
+
+	clip := blockH > h
+	if clip {
+		c._pdf.ClipRect(x, y, w, h, false)
+	}
+	for _, line := range lines {
+		lineY := y + top + line.top
+		if len(line.marker) > 0 {
+			// the marker ends a space before the text
+			markerW := c.runsWidth(line.marker, brush) + c.runWidth(types.TextRun{Text: " ", Style: line.marker[0].Style}, brush)
+			c.drawRunLine(x+line.indent-markerW, lineY, markerW, line.height, RunLine{Runs: line.marker}, brush, "L", false)
+		}
+		c.drawRunLine(x+line.indent, lineY, w-line.indent, line.height, line.RunLine, brush, halign, justify && !line.Last)
+	}
+	if clip {
+		c._pdf.ClipEnd()
+	}
+	c.Restore()
+	c.moveAfterText(x, y, w, h, brush.DisplayStyle)
+}
+
+// Line of a paragraph, placed in the block of lines
+type paragraphLine struct {
+	RunLine
+	marker []types.TextRun // marker of the paragraph, on its first line
+	indent float64
+	top    float64 // offset from the top of the block
+	height float64
+}
+
+// Returns the lines of the paragraphs with the text brush applied, wrapped to the width w when the brush wraps,
+// and the height of the block they form
+func (c *PdfCanvas) layoutParagraphs(paragraphs []types.TextParagraph, w float64, brush *types.TextBrush) (lines []paragraphLine, blockH float64) {
+	if !brush.Wrap {
+		w = math.Inf(1)
+	}
+	width := func(run types.TextRun) float64 {
+		return c.runWidth(run, brush)
+	}
+	for i, p := range paragraphs {
+		if i > 0 {
+			blockH += max(paragraphs[i-1].SpaceAfter, p.SpaceBefore)
+		}
+		indent := p.Indent
+		if len(p.Marker) > 0 {
+			indent = max(indent, c.runsWidth(p.Marker, brush)+width(types.TextRun{Text: " ", Style: p.Marker[0].Style}))
+		}
+		for j, line := range WrapRuns(p.Runs, w-2*c._pdf.GetCellMargin()-indent, width, c.hyphenation(brush)) {
+			pl := paragraphLine{RunLine: line, indent: indent, top: blockH, height: c.lineHeight(line, brush)}
+			if j == 0 && len(p.Marker) > 0 {
+				pl.marker = p.Marker
+				pl.height = max(pl.height, c.lineHeight(RunLine{Runs: p.Marker}, brush))
+			}
+			lines = append(lines, pl)
+			blockH += pl.height
+		}
+	}
+	return lines, blockH
+}
+
+// Draws the runs of the line on a common baseline. Justified lines spread the space left over their spaces.
+func (c *PdfCanvas) drawRunLine(x, y, w, h float64, line RunLine, brush *types.TextBrush, halign string, justify bool) {
+	margin := c._pdf.GetCellMargin()
+	used, spaces := c.runsWidth(line.Runs, brush), 0
+	for _, run := range line.Runs {
+		spaces += strings.Count(run.Text, " ")
+	}
+	extra := 0.
+	runX := x + margin
+	switch {
+	case justify && spaces > 0:
+		extra = (w - 2*margin - used) / float64(spaces)
+	case halign == "C":
+		runX = x + (w-used)/2
+	case halign == "R":
+		runX = x + w - margin - used
+	}
+	// baseline of a middle aligned cell as high as the line
+	baseline := y + .8*h
+	for _, run := range line.Runs {
+		c.withRunStyle(run, brush, func() {
+			if run.Link != "" {
+				defer func(start float64) {
+					c.Link(types.Rect{Left: start, Top: y, Right: runX - start, Bottom: h}, run.Link)
+				}(runX)
+			}
+			if extra == 0 {
+				c._pdf.Text(runX, baseline, run.Text)
+				runX += c._pdf.GetStringWidth(run.Text)
+				return
+			}
+			for i, word := range strings.Split(run.Text, " ") {
+				if i > 0 {
+					runX += c._pdf.GetStringWidth(" ") + extra
+				}
+				if word != "" {
+					c._pdf.Text(runX, baseline, word)
+				}
+				runX += c._pdf.GetStringWidth(word)
+			}
+		})
+	}
+}
+
+// Calls fn with the style of the run applied, the style of the text when the run has none
+func (c *PdfCanvas) withRunStyle(run types.TextRun, brush *types.TextBrush, fn func()) {
+	style := run.Style
+	if style == nil {
+		style = brush
+	}
+	c.Save()
+	c.ApplyTypingBrush(style)
+	fn()
+	c.Restore()
+	// the font family and style are not restored
+	c._pdf.SetFont(brush.FontName, brush.FontStyle.String(), c.GetFontSize())
+}
+
+// Returns the width of the run in its style
+func (c *PdfCanvas) runWidth(run types.TextRun, brush *types.TextBrush) (w float64) {
+	c.withRunStyle(run, brush, func() {
+		w = c._pdf.GetStringWidth(run.Text)
+	})
+	return w
+}
+
+// Returns the height of the tallest font of the line, the font of the text for empty lines
+func (c *PdfCanvas) lineHeight(line RunLine, brush *types.TextBrush) (h float64) {
+	if len(line.Runs) == 0 {
+		return c.GetTextHeight()
+	}
+	for _, run := range line.Runs {
+		c.withRunStyle(run, brush, func() {
+			h = max(h, c.GetTextHeight())
+		})
+	}
+	return h
+}
+
+// Returns the width of the runs in their style
+func (c *PdfCanvas) runsWidth(runs []types.TextRun, brush *types.TextBrush) (w float64) {
+	for _, run := range runs {
+		w += c.runWidth(run, brush)
+	}
+	return w
+}
+
 func (c *PdfCanvas) DrawRect(rect types.Rect, brush *types.Brush) {
 	c.Save()
 	c.ApplyDrawingBrush(brush)
@@ -102,8 +378,57 @@ func (c *PdfCanvas) DrawCircle(x, y, r float64, brush *types.Brush) {
 	c.Restore()
 }
 
-func (c *PdfCanvas) DrawImage(x, y, w, h, float64, brush *types.Brush) {
-	//c._pdf.ImageOptions()
+// Registers the image with the document the first time it is used
+func (c *PdfCanvas) registerImage(img *types.ImageData) *gofpdf.ImageInfoType {
+	if info := c._pdf.GetImageInfo(img.Key); info != nil {
+		return info
+	}
+	// Doesn't report errors immediately. Errors are reported when saving document
+	return c._pdf.RegisterImageOptionsReader(img.Key, gofpdf.ImageOptions{ImageType: img.Type, ReadDpi: true}, bytes.NewReader(img.Data))
+}
+
+func (c *PdfCanvas) MeasureImage(img *types.ImageData) (w, h float64) {
+	info := c.registerImage(img)
+	if info == nil {
+		return 0, 0
+	}
+	return info.Extent()
+}
+
+func (c *PdfCanvas) DrawImage(rect types.Rect, img *types.ImageData, brush *types.ImageBrush) {
+	w, h := c.MeasureImage(img)
+	if w <= 0 || h <= 0 {
+		return
+	}
+	dst, clip := brush.Place(rect, w, h)
+	x, y := c._pdf.GetXY()
+	c.Save()
+	c._pdf.SetAlpha(brush.GetAlpha(), "Normal")
+	if clip {
+		c._pdf.ClipRect(rect.Left, rect.Top, rect.Right, rect.Bottom, false)
+	}
+	c._pdf.ImageOptions(img.Key, dst.Left, dst.Top, dst.Right, dst.Bottom, false, gofpdf.ImageOptions{ImageType: img.Type, ReadDpi: true}, 0, "")
+	if clip {
+		c._pdf.ClipEnd()
+	}
+	c.Restore()
+	c._pdf.SetXY(x, y)
+}
+
+func (c *PdfCanvas) BeginTransform() {
+	c._pdf.TransformBegin()
+}
+
+func (c *PdfCanvas) Rotate(angle, x, y float64) {
+	c._pdf.TransformRotate(angle, x, y)
+}
+
+func (c *PdfCanvas) Translate(dx, dy float64) {
+	c._pdf.TransformTranslate(dx, dy)
+}
+
+func (c *PdfCanvas) EndTransform() {
+	c._pdf.TransformEnd()
 }
 
 func (c *PdfCanvas) DrawLine(x1, y1, x2, y2 float64, brush *types.Brush) {
@@ -124,6 +449,17 @@ func (c *PdfCanvas) GetPageRect() *types.Rect {
 	}
 }
 
+func (c *PdfCanvas) GetMargins() types.Margins {
+	left, top, right, bottom := c._pdf.GetMargins()
+	return types.Margins{Left: left, Top: top, Right: right, Bottom: bottom}
+}
+
+func (c *PdfCanvas) SetMargins(m types.Margins) {
+	c._pdf.SetMargins(m.Left, m.Top, m.Right)
+	auto, _ := c._pdf.GetAutoPageBreak()
+	c._pdf.SetAutoPageBreak(auto, m.Bottom)
+}
+
 // Returns the rect of the drawing area, with margins taken into account
 func (c *PdfCanvas) GetDrawingRect() *types.Rect {
 	w, h := c._pdf.GetPageSize()
@@ -153,6 +489,43 @@ func (c *PdfCanvas) GetTextHeight() float64 {
 	return lh
 }
 
+func (c *PdfCanvas) MeasureText(text string, brush *types.TextBrush) (w, h float64) {
+	if brush.Wrap {
+		return c.MeasureWrappedText(text, math.Inf(1), brush)
+	}
+	c.Save()
+	c.ApplyTypingBrush(brush)
+	w = c._pdf.GetStringWidth(text) + 2*c._pdf.GetCellMargin()
+	h = c.GetTextHeight()
+	c.Restore()
+	return
+}
+
+func (c *PdfCanvas) MeasureWrappedText(text string, w float64, brush *types.TextBrush) (tw, th float64) {
+	c.Save()
+	c.ApplyTypingBrush(brush)
+	lines := c.wrapText(text, w, brush)
+	for _, line := range lines {
+		tw = max(tw, c._pdf.GetStringWidth(line.Text))
+	}
+	tw += 2 * c._pdf.GetCellMargin()
+	th = c.GetTextHeight() * float64(len(lines))
+	c.Restore()
+	return
+}
+
+func (c *PdfCanvas) MeasureRichText(paragraphs []types.TextParagraph, w float64, brush *types.TextBrush) (tw, th float64) {
+	c.Save()
+	c.ApplyTypingBrush(brush)
+	lines, th := c.layoutParagraphs(paragraphs, w, brush)
+	for _, line := range lines {
+		tw = max(tw, line.indent+c.runsWidth(line.Runs, brush))
+	}
+	tw += 2 * c._pdf.GetCellMargin()
+	c.Restore()
+	return
+}
+
 func (c *PdfCanvas) GetX() float64 {
 	return c._pdf.GetX()
 }
@@ -15,17 +15,36 @@ type PdfDocumentImpl struct {
 	_pdf                  *gofpdf.Fpdf
 	currPage              *PdfPageImpl
 	sectionFuncsInstalled bool
+
+	orientation types.PageOrientation
+	pageSize    types.PageSize
+	units       types.DimensionUnit
+	margins     types.Margins
+	pageMargins types.Margins // margins of the page being added
+	pageBreaks  bool          // automatic page breaks of the page being added
+	anchors     *anchorTable
 }
 
 type PdfPageImpl struct {
-	_pdf *gofpdf.Fpdf
+	_pdf    *gofpdf.Fpdf
+	anchors *anchorTable
 }
 
-func (d *PdfDocumentImpl) AddNewPage(footerNHeaderFn func(p types.PdfPage, pageIndex int, inFooter bool)) types.PdfPage {
-	d.currPage = &PdfPageImpl{_pdf: d._pdf}
+func (d *PdfDocumentImpl) AddNewPage(setup *types.PageSetup, footerNHeaderFn func(p types.PdfPage, pageIndex int, inFooter bool)) types.PdfPage {
+	d.currPage = &PdfPageImpl{_pdf: d._pdf, anchors: d.anchors}
+	d.pageMargins = d.margins
+	if setup != nil && setup.Margins != nil {
+		d.pageMargins = *setup.Margins
+	}
+	d.pageBreaks = setup == nil || !setup.NoAutoPageBreak
 	if !d.sectionFuncsInstalled {
 		d.sectionFuncsInstalled = true
 		d._pdf.SetHeaderFuncMode(func() {
+			// margins change once the footer of the previous page is rendered
+			m := d.pageMargins
+			d._pdf.SetMargins(m.Left, m.Top, m.Right)
+			d._pdf.SetAutoPageBreak(d.pageBreaks, m.Bottom)
+			d._pdf.SetXY(m.Left, m.Top)
 			if footerNHeaderFn != nil {
 				footerNHeaderFn(d.currPage, d._pdf.PageNo()-1, false)
 			}
@@ -37,22 +56,58 @@ func (d *PdfDocumentImpl) AddNewPage(footerNHeaderFn func(p types.PdfPage, pageI
 			}
 		})
 	}
-	d._pdf.AddPage() // above callbacks are called by this function
+	orientation, size := d.pageFormat(setup)
+	d._pdf.AddPageFormat(string(orientation), size) // above callbacks are called by this function
 	return d.currPage
 }
 
+// Returns the orientation and the portrait size of pages with the given setup
+func (d *PdfDocumentImpl) pageFormat(setup *types.PageSetup) (types.PageOrientation, gofpdf.SizeType) {
+	orientation, pageSize := d.orientation, d.pageSize
+	if setup != nil && setup.Orientation != "" {
+		orientation = setup.Orientation
+	}
+	if setup != nil && setup.Size != "" {
+		pageSize = setup.Size
+	}
+	if w, h, ok, _ := pageSize.Dimensions(d.units); ok {
+		return orientation, gofpdf.SizeType{Wd: w, Ht: h}
+	}
+	return orientation, d._pdf.GetPageSizeStr(string(pageSize))
+}
+
+func (d *PdfDocumentImpl) NewLayoutDocument() (types.PdfDocument, error) {
+	return NewPdfDocument(d.orientation, d.pageSize, d.units)
+}
+
+func (d *PdfDocumentImpl) GetPageSize(setup *types.PageSetup) (w, h float64) {
+	orientation, size := d.pageFormat(setup)
+	if orientation == types.PO_LANDSCAPE {
+		return size.Ht, size.Wd
+	}
+	return size.Wd, size.Ht
+}
+
+func (d *PdfDocumentImpl) GetMargins() types.Margins {
+	return d.margins
+}
+
 func (d *PdfDocumentImpl) SetTitle(title string) {
 	d._pdf.SetTitle(title, true)
 }
 
-func (d *PdfDocumentImpl) SetBookmark(title string) {
-	d._pdf.Bookmark(title, -1, -1)
+func (d *PdfDocumentImpl) SetBookmark(title string, level int, y float64) {
+	d._pdf.Bookmark(title, level, y)
+}
+
+func (d *PdfDocumentImpl) GetAnchor(name string) (page int, y float64, ok bool) {
+	return d.anchors.get(name)
 }
 
 func (d *PdfDocumentImpl) GetPage(ipage int) (types.PdfPage, bool) {
 	if ipage <= d._pdf.PageCount() {
 		d._pdf.SetPage(ipage)
-		return &PdfPageImpl{_pdf: d._pdf}, true
+		return &PdfPageImpl{_pdf: d._pdf, anchors: d.anchors}, true
 	}
 	return nil, false
 }
@@ -65,15 +120,30 @@ func (d *PdfDocumentImpl) AddFont(fontname string, data []byte) {
 	d._pdf.AddFontFromBytes(fontname, "", nil, data)
 }
 
+// Closes the document, rendering the last footer, and checks that every anchor linked to is set
+func (d *PdfDocumentImpl) close() error {
+	d._pdf.Close()
+	return d.anchors.check()
+}
+
 func (d *PdfDocumentImpl) SaveAndCloseF(dst string) error {
+	if err := d.close(); err != nil {
+		return err
+	}
 	return d._pdf.OutputFileAndClose(dst)
 }
 
 func (d *PdfDocumentImpl) SaveAndCloseW(w io.WriteCloser) error {
+	if err := d.close(); err != nil {
+		return err
+	}
 	return d._pdf.OutputAndClose(w)
 }
 
 func (d *PdfDocumentImpl) Save(w io.Writer) error {
+	if err := d.close(); err != nil {
+		return err
+	}
 	return d._pdf.Output(w)
 }
 
@@ -111,14 +181,29 @@ func (d *PdfDocumentImpl) InitializeFonts(fonts *[]*types.Font) error {
 }
 
 func (p *PdfPageImpl) GetCanvas() types.Canvas {
-	return &PdfCanvas{_pdf: p._pdf}
+	return &PdfCanvas{_pdf: p._pdf, anchors: p.anchors}
 }
 
 func NewPdfDocument(orientation types.PageOrientation, pageSize types.PageSize, units types.DimensionUnit) (types.PdfDocument, error) {
 	if units == types.DU_PERCENT {
 		return nil, errors.New("'%' unit cannot be used at document root level")
 	}
-	pdf := gofpdf.New(string(orientation), units.String(), string(pageSize), "")
+	init := &gofpdf.InitType{OrientationStr: string(orientation), UnitStr: units.String(), SizeStr: string(pageSize)}
+	w, h, custom, err := pageSize.Dimensions(units)
+	if err != nil {
+		return nil, err
+	}
+	if custom {
+		init.Size = gofpdf.SizeType{Wd: w, Ht: h}
+	}
+	pdf := gofpdf.NewCustom(init)
+	if err := pdf.Error(); err != nil {
+		return nil, err
+	}
 	pdf.SetFont("courier", "", 12)
-	return &PdfDocumentImpl{_pdf: pdf}, nil
+
+	left, top, right, _ := pdf.GetMargins()
+	_, bottomMargin := pdf.GetAutoPageBreak()
+	margins := types.Margins{Left: left, Top: top, Right: right, Bottom: bottomMargin}
+	return &PdfDocumentImpl{_pdf: pdf, orientation: orientation, pageSize: pageSize, units: units, margins: margins, anchors: &anchorTable{}}, nil
 }
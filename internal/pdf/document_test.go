@@ -0,0 +1,31 @@
+package pdf_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/pdf"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoPageBreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup *types.PageSetup
+		pages int
+	}{
+		{"document default", nil, 2},
+		{"left to the caller", &types.PageSetup{NoAutoPageBreak: true}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := pdf.NewPdfDocument(types.PO_PORTRAIT, types.PAGE_SIZE_A4, types.DU_MILIMETER)
+			assert.Nil(t, err)
+			c := doc.AddNewPage(tt.setup, nil).GetCanvas()
+			c.SetMargins(types.Margins{Left: 10, Top: 10, Right: 10, Bottom: 20})
+			c.SetY(280)
+			c.DrawText(50, 10, "below the bottom margin", &types.TextBrush{})
+			assert.Equal(t, tt.pages, doc.GetPageCount())
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package pdf
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+)
+
+// Line of wrapped text
+type TextLine struct {
+	Text string
+	Last bool // last line of its paragraph, left as is by justification
+}
+
+// Line of wrapped runs. Words are separated by runs of a single space.
+type RunLine struct {
+	Runs []types.TextRun
+	Last bool // last line of its paragraph, left as is by justification
+}
+
+// Returns the text of the line
+func (l RunLine) Text() string {
+	var sb strings.Builder
+	for _, run := range l.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// Word of runs, split where the style or the link changes
+type runWord struct {
+	parts []types.TextRun
+	space types.TextRun // style and link of the space before the word
+}
+
+func (w runWord) text() string {
+	return RunLine{Runs: w.parts}.Text()
+}
+
+// Returns the runes [from, to) of the word
+func (w runWord) slice(from, to int) runWord {
+	sliced := runWord{space: w.space}
+	pos := 0
+	for _, part := range w.parts {
+		runes := []rune(part.Text)
+		start, end := max(from-pos, 0), min(to-pos, len(runes))
+		if start < end {
+			sliced.parts = append(sliced.parts, types.TextRun{Text: string(runes[start:end]), Style: part.Style, Link: part.Link})
+		}
+		pos += len(runes)
+	}
+	return sliced
+}
+
+// Splits the runs into paragraphs at newlines, and paragraphs into words at whitespace
+func splitWords(runs []types.TextRun) [][]runWord {
+	paragraphs := [][]runWord{{}}
+	word := runWord{}
+	flush := func() {
+		if len(word.parts) > 0 {
+			paragraphs[len(paragraphs)-1] = append(paragraphs[len(paragraphs)-1], word)
+		}
+		word = runWord{}
+	}
+	for _, run := range runs {
+		for _, r := range strings.ReplaceAll(run.Text, "\r\n", "\n") {
+			switch {
+			case r == '\n':
+				flush()
+				paragraphs = append(paragraphs, []runWord{})
+			case unicode.IsSpace(r):
+				flush()
+				word.space = types.TextRun{Style: run.Style, Link: run.Link}
+			case len(word.parts) > 0 && sameFormat(word.parts[len(word.parts)-1], run):
+				word.parts[len(word.parts)-1].Text += string(r)
+			default:
+				word.parts = append(word.parts, types.TextRun{Text: string(r), Style: run.Style, Link: run.Link})
+			}
+		}
+	}
+	flush()
+	return paragraphs
+}
+
+func sameFormat(a, b types.TextRun) bool {
+	return a.Style == b.Style && a.Link == b.Link
+}
+
+// Joins the words with spaces, merging neighbouring runs of the same style and link
+func joinWords(words []runWord) []types.TextRun {
+	runs := []types.TextRun{}
+	add := func(run types.TextRun) {
+		if n := len(runs); n > 0 && sameFormat(runs[n-1], run) {
+			runs[n-1].Text += run.Text
+			return
+		}
+		runs = append(runs, run)
+	}
+	for i, word := range words {
+		if i > 0 {
+			add(types.TextRun{Text: " ", Style: word.space.Style, Link: word.space.Link})
+		}
+		for _, part := range word.parts {
+			add(part)
+		}
+	}
+	return runs
+}
+
+// Breaks the runs into lines no wider than w, at explicit newlines and between words. width returns the width
+// of a run in its style. Words that do not fit are hyphenated at the points returned by hyphenate when set,
+// and words wider than w are broken between characters.
+func WrapRuns(runs []types.TextRun, w float64, width func(run types.TextRun) float64, hyphenate func(word string) []int) []RunLine {
+	fits := func(words []runWord) bool {
+		lineW := 0.
+		for _, run := range joinWords(words) {
+			lineW += width(run)
+		}
+		return lineW <= w
+	}
+	lines := []RunLine{}
+	for _, paragraph := range splitWords(runs) {
+		line := []runWord{}
+		emit := func(words []runWord) {
+			lines = append(lines, RunLine{Runs: joinWords(words)})
+			line = []runWord{}
+		}
+		for _, word := range paragraph {
+			text := word.text()
+			length := len([]rune(text))
+			points := []int{}
+			if hyphenate != nil {
+				points = hyphenate(text)
+			}
+			for start := 0; ; {
+				if candidate := append(slices.Clip(line), word.slice(start, length)); fits(candidate) {
+					line = candidate
+					break
+				}
+				// longest part of the word that fits on the line with a hyphen
+				hyphenated := false
+				for i := len(points) - 1; i >= 0 && points[i] > start; i-- {
+					head := word.slice(start, points[i])
+					if last := &head.parts[len(head.parts)-1]; !strings.HasSuffix(last.Text, "-") {
+						last.Text += "-"
+					}
+					if candidate := append(slices.Clip(line), head); fits(candidate) {
+						emit(candidate)
+						start, hyphenated = points[i], true
+						break
+					}
+				}
+				if hyphenated {
+					continue
+				}
+				if len(line) > 0 {
+					emit(line)
+					continue
+				}
+				// break words that do not fit on a line of their own
+				from := start
+				for i := start + 1; i <= length; i++ {
+					if i-1 > from && !fits([]runWord{word.slice(from, i)}) {
+						emit([]runWord{word.slice(from, i-1)})
+						from = i - 1
+					}
+				}
+				line = []runWord{word.slice(from, length)}
+				break
+			}
+		}
+		lines = append(lines, RunLine{Runs: joinWords(line), Last: true})
+	}
+	return lines
+}
+
+// Breaks the text into lines no wider than w, at explicit newlines and between words. width returns the width of
+// a string. Words that do not fit are hyphenated at the points returned by hyphenate when set, and words wider
+// than w are broken between characters.
+func WrapText(text string, w float64, width func(s string) float64, hyphenate func(word string) []int) []TextLine {
+	runLines := WrapRuns([]types.TextRun{{Text: text}}, w, func(run types.TextRun) float64 { return width(run.Text) }, hyphenate)
+	lines := make([]TextLine, len(runLines))
+	for i, line := range runLines {
+		lines[i] = TextLine{Text: line.Text(), Last: line.Last}
+	}
+	return lines
+}
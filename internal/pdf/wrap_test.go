@@ -0,0 +1,56 @@
+package pdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/internal/pdf"
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapText(t *testing.T) {
+	width := func(s string) float64 { return float64(len(s)) }
+	texts := func(lines []pdf.TextLine) []string {
+		s := []string{}
+		for _, l := range lines {
+			s = append(s, l.Text)
+		}
+		return s
+	}
+	assert.Equal(t, []string{"the quick", "brown fox"}, texts(pdf.WrapText("the quick brown fox", 10, width, nil)))
+	assert.Equal(t, []string{"first", "", "second line"}, texts(pdf.WrapText("first\n\nsecond line", 12, width, nil)))
+	assert.Equal(t, []string{"a", "abcd", "efgh", "ij"}, texts(pdf.WrapText("a abcdefghij", 4, width, nil)))
+	assert.Equal(t, []pdf.TextLine{{Text: "", Last: true}}, pdf.WrapText("", 4, width, nil))
+
+	lines := pdf.WrapText("one two\nthree", 7, width, nil)
+	assert.Equal(t, []pdf.TextLine{{Text: "one two", Last: true}, {Text: "three", Last: true}}, lines)
+
+	hyphenate := func(word string) []int { return []int{2, 6} } // hy-phen-ation
+	assert.Equal(t, []string{"a hy-", "phen-", "ation"}, texts(pdf.WrapText("a hyphenation", 6, width, hyphenate)))
+}
+
+func TestWrapRuns(t *testing.T) {
+	bold := &types.TextBrush{}
+	width := func(run types.TextRun) float64 {
+		if run.Style == bold {
+			return 2 * float64(len(run.Text))
+		}
+		return float64(len(run.Text))
+	}
+	runs := []types.TextRun{{Text: "Total: "}, {Text: "$1,200", Style: bold}, {Text: ", due now"}}
+
+	lines := pdf.WrapRuns(runs, 20, width, nil)
+	assert.Equal(t, []pdf.RunLine{
+		{Runs: []types.TextRun{{Text: "Total: "}, {Text: "$1,200", Style: bold}, {Text: ","}}},
+		{Runs: []types.TextRun{{Text: "due now"}}, Last: true},
+	}, lines)
+
+	lines = pdf.WrapRuns(runs, math.Inf(1), width, nil)
+	assert.Equal(t, []pdf.RunLine{{Runs: runs, Last: true}}, lines)
+
+	// links are kept apart from unlinked text of the same style, spaces included
+	runs = []types.TextRun{{Text: "see "}, {Text: "our portal", Link: "https://example.com"}, {Text: " now"}}
+	lines = pdf.WrapRuns(runs, math.Inf(1), width, nil)
+	assert.Equal(t, []pdf.RunLine{{Runs: runs, Last: true}}, lines)
+}
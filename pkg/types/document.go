@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"slices"
@@ -32,6 +33,54 @@ const (
 	PO_PORTRAIT  = "P"
 )
 
+// Named page sizes
+var standardPageSizes = []string{"a1", "a2", "a3", "a4", "a5", "a6", "letter", "legal", "tabloid"}
+
+// Validates a named page size or a custom `WxH` size
+func (s PageSize) Validate() error {
+	if slices.Contains(standardPageSizes, strings.ToLower(string(s))) {
+		return nil
+	}
+	if _, _, ok, err := s.Dimensions(DU_MILIMETER); !ok || err != nil {
+		return errors.Errorf("invalid page size `%s`, expected a named size or `WxH`, e.g. `100mm x 150mm`", s)
+	}
+	return nil
+}
+
+// Returns the width and height of a custom `WxH` page size in the given unit, e.g. `100mm x 150mm`.
+// ok is false for named sizes.
+func (s PageSize) Dimensions(unit DimensionUnit) (w, h float64, ok bool, err error) {
+	sides := strings.Split(strings.ToLower(string(s)), "x")
+	if len(sides) != 2 {
+		return 0, 0, false, nil
+	}
+	var dims [2]Dimension
+	for i, side := range sides {
+		if err := dims[i].UnmarshalText([]byte(strings.TrimSpace(side))); err != nil {
+			return 0, 0, true, err
+		}
+		if dims[i].Unit == DU_PERCENT || dims[i].Value <= 0 {
+			return 0, 0, true, errors.Errorf("invalid page size `%s`", s)
+		}
+	}
+	w = dims[0].GetValue(0, 0, 0, UT_LENGTH, unit)
+	h = dims[1].GetValue(0, 0, 0, UT_LENGTH, unit)
+	return w, h, true, nil
+}
+
+// Parses `P`, `L`, `portrait` or `landscape`
+func (o *PageOrientation) Parse(in string) error {
+	switch strings.ToLower(strings.TrimSpace(in)) {
+	case "p", "portrait":
+		*o = PO_PORTRAIT
+	case "l", "landscape":
+		*o = PO_LANDSCAPE
+	default:
+		return errors.Errorf("invalid page orientation `%s`, expected `portrait` or `landscape`", in)
+	}
+	return nil
+}
+
 var stringToCellDisplayMap map[string]CellDisplay = map[string]CellDisplay{
 	"row":    DISPLAY_ROW,
 	"stack":  DISPLAY_STACK,
@@ -85,6 +134,21 @@ const (
 
 	// Document element. Root element
 	DOCUMENT
+
+	// Table element, hosted by a cell
+	TABLE
+
+	// Row of a table
+	TABLE_ROW
+
+	// Group of pages of the document
+	SECTION
+
+	// Run of text within a cell
+	SPAN
+
+	// Table of contents, hosted by a cell
+	TOC
 )
 
 const (
@@ -98,6 +162,18 @@ const (
 	DISPLAY_STACK
 )
 
+// Edges of an element's border
+type BorderEdge int
+
+const (
+	BE_LEFT BorderEdge = 1 << iota
+	BE_TOP
+	BE_RIGHT
+	BE_BOTTOM
+
+	BE_ALL = BE_LEFT | BE_TOP | BE_RIGHT | BE_BOTTOM
+)
+
 type DimensionUnit int
 
 const (
@@ -294,7 +370,7 @@ var (
 		DU_INCH: {
 			DU_CENTIMETER: func(in float64, flags UnitType, w, h, fontSize float64) float64 { return in * 2.54 },
 			DU_INCH:       func(in float64, flags UnitType, w, h, fontSize float64) float64 { return in },
-			DU_MILIMETER:  func(in float64, flags UnitType, w, h, fontSize float64) float64 { return 25.4 },
+			DU_MILIMETER:  func(in float64, flags UnitType, w, h, fontSize float64) float64 { return in * 25.4 },
 			//DU_PERCENT:    func(in float64, flags UnitType) float64 {},
 		},
 		DU_PERCENT: {
@@ -361,7 +437,11 @@ func (me *Dimension) UnmarshalText(data []byte) error {
 		return errors.Wrap(err, "invalid unit value")
 	}
 	me.OriginalValue = value
-	me.Value = value / 100.00 // why??
+	me.Value = value
+	if me.Unit == DU_PERCENT {
+		// percentages are stored as fractions of the parent value
+		me.Value = value / 100.00
+	}
 	return nil
 }
 
@@ -383,12 +463,16 @@ type PageElement ElementType
 type HeaderElement ElementType
 type FooterElement ElementType
 type CellElement ElementType
+type TableElement ElementType
+type TableRowElement ElementType
 
 const E_DOCUMENT DocumentElement = 0
 const E_HEADER HeaderElement = 0
 const E_FOOTER FooterElement = 0
 const E_PAGE PageElement = 0
 const E_CELL CellElement = 0
+const E_TABLE TableElement = 0
+const E_TABLE_ROW TableRowElement = 0
 
 type Element struct {
 	Attrs     []*Attribute `json:"attributes"`
@@ -396,6 +480,8 @@ type Element struct {
 
 	TextStyle     TextBrush `json:"-"`
 	BookmarkTitle string    `json:"bookmark_title"`
+	BookmarkLevel int       `json:"-"` // Outline level of the bookmark, from 1. Derived from the parent bookmarks when 0
+	BookmarkTo    string    `json:"-"` // Anchor the bookmark points to, in place of the position of the element
 	Brush         Brush     `json:"-"`
 	Border        struct {
 		Left   *Border
@@ -404,6 +490,9 @@ type Element struct {
 		Bottom *Border
 	} `json:"-"`
 	Background *Brush `json:"-"`
+
+	Condition         string              `json:"-"` // Render condition. The element is skipped when it evaluates to false
+	ConditionalStyles []*ConditionalStyle `json:"-"` // Styles applied at render time when their condition holds
 }
 
 func (e *Element) GetAttributeValue(name string) (string, bool) {
@@ -415,6 +504,21 @@ func (e *Element) GetAttributeValue(name string) (string, bool) {
 	return "", false
 }
 
+// Returns a copy of the element whose brushes and borders can be modified without affecting the original
+func (e Element) Clone() Element {
+	if e.Background != nil {
+		e.Background = e.Background.clone()
+	}
+	for _, b := range []**Border{&e.Border.Left, &e.Border.Top, &e.Border.Right, &e.Border.Bottom} {
+		if *b != nil {
+			border := **b
+			border.Brush = *border.Brush.clone()
+			*b = &border
+		}
+	}
+	return e
+}
+
 func (e *Element) InitBorders() {
 	newBrush := func() *Brush {
 		return &Brush{
@@ -463,19 +567,24 @@ func (e *Element) SetBorderColor(color int, alpha float64) {
 }
 
 func (e Element) DrawBorder(c Canvas, left, top, right, bottom float64) {
-	if e.Border.Left != nil {
+	e.DrawBorderEdges(c, left, top, right, bottom, BE_ALL)
+}
+
+// Draws the borders of the given edges only. Used to stroke edges shared by adjacent cells once.
+func (e Element) DrawBorderEdges(c Canvas, left, top, right, bottom float64, edges BorderEdge) {
+	if e.Border.Left != nil && edges&BE_LEFT > 0 {
 		c.DrawLine(left, top, left, bottom, &e.Border.Left.Brush)
 	}
 
-	if e.Border.Right != nil {
+	if e.Border.Right != nil && edges&BE_RIGHT > 0 {
 		c.DrawLine(right, top, right, bottom, &e.Border.Right.Brush)
 	}
 
-	if e.Border.Top != nil {
+	if e.Border.Top != nil && edges&BE_TOP > 0 {
 		c.DrawLine(left, top, right, top, &e.Border.Top.Brush)
 	}
 
-	if e.Border.Bottom != nil {
+	if e.Border.Bottom != nil && edges&BE_BOTTOM > 0 {
 		c.DrawLine(left, bottom, right, bottom, &e.Border.Bottom.Brush)
 	}
 }
@@ -501,32 +610,257 @@ func (me *Element) Inherit(parent *Element) {
 	me.TextStyle.Copy(&parent.TextStyle)
 }
 
+// Inherit parent borders. Table rows and cells take the borders of their table unless they set their own.
+func (me *Element) InheritBorders(parent *Element) {
+	for _, b := range []struct{ dst, src **Border }{
+		{&me.Border.Left, &parent.Border.Left},
+		{&me.Border.Top, &parent.Border.Top},
+		{&me.Border.Right, &parent.Border.Right},
+		{&me.Border.Bottom, &parent.Border.Bottom},
+	} {
+		if *b.src != nil {
+			border := **b.src
+			*b.dst = &border
+		}
+	}
+}
+
+// Band of the page a header or footer is laid out in
+type SectionArea struct {
+	Height *Dimension `json:"-"` // Height of the band, reserved by moving the page margin. Omit to use the page margin as is
+
+	// Distance between the band and the page edge on its side, the left page margin by default, and between the band and
+	// the page content on the other side, none by default. The left and right sides default to the page margins
+	Margins Spacing `json:"-"`
+	Padding Spacing `json:"-"` // Space between the edges of the band and its cells
+}
+
+// Returns the margins of the band and its height. Percentages resolve against the page, and the left and right margins
+// default to those of the page. ok is false when the section has no height.
+func (s *SectionArea) GetExtent(page Margins, pageW, pageH float64, footer bool, unit DimensionUnit) (margins Margins, height float64, ok bool) {
+	defaults := Margins{Left: page.Left, Top: page.Left, Right: page.Right}
+	if footer {
+		defaults.Top, defaults.Bottom = 0, page.Left
+	}
+	margins = s.Margins.Resolve(pageW, pageH, defaults, unit)
+	if s.Height == nil {
+		return margins, 0, false
+	}
+	return margins, s.Height.GetValue(0, pageH, 0, UT_LENGTH|UT_LENGTH_HEIGHT, unit), true
+}
+
+// Selects which header or footer is rendered on a page
+type SectionVariant string
+
+const (
+	SV_DEFAULT SectionVariant = "default" // The header or footer itself, rendered when no other variant applies
+	SV_FIRST   SectionVariant = "first"   // First page of the document
+	SV_ODD     SectionVariant = "odd"     // Odd page numbers
+	SV_EVEN    SectionVariant = "even"    // Even page numbers
+	SV_LAST    SectionVariant = "last"    // Last page of the document
+)
+
+func (v *SectionVariant) Parse(in string) error {
+	return parseEnum(v, in, "section variant", SV_DEFAULT, SV_FIRST, SV_ODD, SV_EVEN, SV_LAST)
+}
+
+// Returns the variant of a page, in order of first, last, odd or even. has reports whether a variant is defined.
+func autoSectionVariant(pageNo int, first, last bool, has func(SectionVariant) bool) SectionVariant {
+	switch {
+	case first && has(SV_FIRST):
+		return SV_FIRST
+	case last && has(SV_LAST):
+		return SV_LAST
+	case pageNo%2 == 1 && has(SV_ODD):
+		return SV_ODD
+	case pageNo%2 == 0 && has(SV_EVEN):
+		return SV_EVEN
+	}
+	return SV_DEFAULT
+}
+
 type Header struct {
 	Element
-	Cells []*Cell `json:"cells"`
+	SectionArea
+	Cells    []*Cell                    `json:"cells"`
+	Variants map[SectionVariant]*Header `json:"variants,omitempty"` // Headers of the first, odd, even or last pages
+}
+
+// Returns the header of page pageNo, the first or the last page of the header. An empty variant selects it from the page.
+func (h *Header) Select(variant SectionVariant, pageNo int, first, last bool) *Header {
+	if variant == "" {
+		variant = autoSectionVariant(pageNo, first, last, func(v SectionVariant) bool { return h.Variants[v] != nil })
+	}
+	if section := h.Variants[variant]; section != nil {
+		return section
+	}
+	return h
 }
 
 type Page struct {
 	Element
 	Cells []*Cell `json:"cells"`
+	Flow  bool    `json:"-"` // Continue on a new page when cells overflow the drawing area
+
+	NoWatermark bool   `json:"-"` // Skip the document watermark on this page
+	Anchor      string `json:"-"` // Name of the anchor set at the top of the page, where its bookmark points
 
-	PageIndex int `json:"-"` // used internally
+	PageSize    PageSize        `json:"-"` // Size of the page. Defaults to the size of the section or document
+	Orientation PageOrientation `json:"-"` // Orientation of the page. Defaults to the orientation of the section or document
+
+	HeaderVariant SectionVariant `json:"-"` // Header variant of the page. Selected from the page number when empty
+	FooterVariant SectionVariant `json:"-"` // Footer variant of the page. Selected from the page number when empty
+	NoHeader      bool           `json:"-"` // Skip the header on this page
+	NoFooter      bool           `json:"-"` // Skip the footer on this page
+
+	Margins Spacing `json:"-"` // Margins of the page. Unset sides keep the margins of the section or document
+	Padding Spacing `json:"-"` // Space between the background and border of the page and its cells
+
+	PageIndex int      `json:"-"` // used internally
+	Section   *Section `json:"-"` // section of the page, nil for document pages
+}
+
+// Margins or padding as set in the template. Unset sides keep their default
+type Spacing struct {
+	Left, Top, Right, Bottom *Dimension
+}
+
+func (m *Spacing) IsZero() bool {
+	return m.Left == nil && m.Top == nil && m.Right == nil && m.Bottom == nil
+}
+
+// Parses one to four space separated dimensions, in the order of CSS margins: top, right, bottom, left
+func (m *Spacing) Parse(in string) error {
+	fields := strings.Fields(in)
+	if len(fields) == 0 || len(fields) > 4 {
+		return errors.Errorf("invalid spacing `%s`, expected one to four dimensions", in)
+	}
+	values := make([]*Dimension, len(fields))
+	for i, field := range fields {
+		values[i] = new(Dimension)
+		if err := values[i].UnmarshalText([]byte(field)); err != nil {
+			return err
+		}
+	}
+	// missing sides repeat the opposite side, as in CSS
+	if len(values) == 1 {
+		values = append(values, values[0])
+	}
+	for len(values) < 4 {
+		values = append(values, values[len(values)-2])
+	}
+	m.Top, m.Right, m.Bottom, m.Left = values[0], values[1], values[2], values[3]
+	return nil
+}
+
+// Returns the spacing of a box within w, h. Percentages resolve against the width or the height.
+func (m *Spacing) Resolve(w, h float64, defaults Margins, unit DimensionUnit) Margins {
+	resolved := defaults
+	for _, side := range []struct {
+		src   *Dimension
+		dst   *float64
+		flags UnitType
+	}{
+		{m.Left, &resolved.Left, UT_LENGTH | UT_LENGH_WIDTH},
+		{m.Top, &resolved.Top, UT_LENGTH | UT_LENGTH_HEIGHT},
+		{m.Right, &resolved.Right, UT_LENGTH | UT_LENGH_WIDTH},
+		{m.Bottom, &resolved.Bottom, UT_LENGTH | UT_LENGTH_HEIGHT},
+	} {
+		if side.src != nil {
+			*side.dst = side.src.GetValue(w, h, 0, side.flags, unit)
+		}
+	}
+	return resolved
+}
+
+// Group of pages with their own page setup, header, footer and page numbering
+type Section struct {
+	Element
+	PageSize             PageSize        `json:"size,omitempty"`                   // Size of the section pages. Defaults to the document size
+	Orientation          PageOrientation `json:"orientation,omitempty"`            // Orientation of the section pages. Defaults to the document orientation
+	Foot                 *Footer         `json:"footer,omitempty"`                 // Footer of the section pages. Defaults to the document footer
+	Head                 *Header         `json:"header,omitempty"`                 // Header of the section pages. Defaults to the document header
+	Pages                []*Page         `json:"pages"`                            // Pages
+	PageBookmarkTemplate string          `json:"page_bookmark_template,omitempty"` // Template for the page bookmarks of the section. Defaults to the document template
+
+	Margins    Spacing `json:"-"` // Margins of the section pages. Unset sides keep the document margins
+	PageNumber int     `json:"-"` // Restarts the page numbering at the given number when above 0
 }
 
 type Footer struct {
 	Element
-	Cells []*Cell `json:"cells"`
+	SectionArea
+	Cells    []*Cell                    `json:"cells"`
+	Variants map[SectionVariant]*Footer `json:"variants,omitempty"` // Footers of the first, odd, even or last pages
 }
 
+// Returns the footer of page pageNo, the first or the last page of the footer. An empty variant selects it from the page.
+func (f *Footer) Select(variant SectionVariant, pageNo int, first, last bool) *Footer {
+	if variant == "" {
+		variant = autoSectionVariant(pageNo, first, last, func(v SectionVariant) bool { return f.Variants[v] != nil })
+	}
+	if section := f.Variants[variant]; section != nil {
+		return section
+	}
+	return f
+}
+
+// A cell with child cells is a group. Groups are not drawn themselves, their children are rendered in place instead.
+// Groups with a width, a height or a layout are containers: they are drawn as a box and their children are laid out inside it,
+// relative to its top left corner. Percentages of children resolve against the container.
 type Cell struct {
 	Element
-	Text string `json:"text"` // Text to render. Empty string will render a blank box. Use height and width to control size.
-
-	Width    *Dimension `json:"-"` // Width of cell. Omit to use font width
-	Height   *Dimension `json:"-"` // Height of cell. Omit to use font size
+	Text  string  `json:"text"`            // Text to render. Empty string will render a blank box. Use height and width to control size.
+	Spans []*Span `json:"spans,omitempty"` // Runs of text with their own font, rendered in place of the text
+	Cells []*Cell `json:"cells,omitempty"` // Child cells of a group
+	Table *Table  `json:"table,omitempty"` // Table rendered in place of the cell
+	Toc   *Toc    `json:"toc,omitempty"`   // Table of contents rendered in place of the cell
+
+	Width    *Dimension `json:"-"` // Width of cell, padding included. Omit to use font width
+	Height   *Dimension `json:"-"` // Height of cell, padding included. Omit to use font size, or to fit wrapped text
+	Padding  Spacing    `json:"-"` // Space between the border of the cell and its content
+	Margin   Spacing    `json:"-"` // Space around the border of the cell. Not applied to table cells
 	Absolute bool       `json:"-"` // Render cell at an absolute position`
 	Left     float64    `json:"-"` // Left position if absolute
 	Top      float64    `json:"-"` // Top position if absolute
+	Repeat   string     `json:"-"` // Repeat expression: `items`, `item in items` or `item, index in items`. Renders the cell once per array element
+	ColSpan  int        `json:"-"` // Number of table columns the cell spans. Table cells only
+	RowSpan  int        `json:"-"` // Number of table rows the cell spans. Table cells only
+
+	Layout LayoutMode `json:"-"` // Layout of the children of a container. Defaults to flow
+	Gap    *Dimension `json:"-"` // Space between the children of flex and grid containers
+	Flex   FlexLayout `json:"-"` // Flex layout settings, when the layout is flex
+	Grid   GridLayout `json:"-"` // Grid layout settings, when the layout is grid
+	FlexItem
+	GridItem GridPlacement `json:"-"` // Placement in the parent grid container
+
+	Href   string `json:"-"` // Target the cell links to, a URL or `#` followed by the name of an anchor
+	Anchor string `json:"-"` // Name of the anchor set at the top of the cell
+
+	ContentType ContentType     `json:"-"` // How the text is rendered. Defaults to plain text
+	RichText    []TextParagraph `json:"-"` // Paragraphs rendered in place of the text, resolved from markdown text
+
+	Image      string     `json:"-"` // Image source: `file://` path, base64 data or the name of a document image
+	ImageData  *ImageData `json:"-"` // Image loaded from the source
+	ImageStyle ImageBrush `json:"-"` // How the image is fitted and aligned in the cell
+}
+
+// Run of text within a cell. Spans take the font of their cell, which their attributes and styles override.
+type Span struct {
+	Element
+	Text string `json:"text"`
+}
+
+// Selects how the text of a cell is rendered
+type ContentType string
+
+const (
+	CT_TEXT     ContentType = "text"     // Text drawn as is
+	CT_MARKDOWN ContentType = "markdown" // Markdown paragraphs, headings, lists, emphasis, inline code and links
+)
+
+func (ct *ContentType) Parse(in string) error {
+	return parseEnum(ct, in, "content type", CT_TEXT, CT_MARKDOWN)
 }
 
 type Style struct {
@@ -534,6 +868,12 @@ type Style struct {
 	Attributes []*Attribute `json:"attributes"`
 }
 
+// Style applied only when its condition evaluates to true. Declared in style lists as `style-name if condition`
+type ConditionalStyle struct {
+	Style     *Style
+	Condition string
+}
+
 func (s *Style) Apply(e *Element) {
 	e.Attrs = append(e.Attrs, s.Attributes...)
 }
@@ -589,6 +929,7 @@ type Document struct {
 	Element
 	Styles               []*Style        `json:"styles"`
 	Fonts                []*Font         `json:"fonts"`
+	Images               []*Image        `json:"images,omitempty"`       // Images cells can refer to by name
 	PageSize             PageSize        `json:"size,omitempty"`         // Document size: (A4,Letter, etc)
 	DisplayUnit          DimensionUnit   `json:"units,omitempty"`        // Document display units. All numbers will eventually be converted to this unit
 	Orientation          PageOrientation `json:"orientation,omitempty"`  // Orientation: (P)ortrait or (L)andscape
@@ -597,16 +938,11 @@ type Document struct {
 	Pages                []*Page         `json:"pages"`                  // Pages
 	PageBookmarks        bool            `json:"bookmarks"`              // Whether to show page bookmarks
 	PageBookmarkTemplate string          `json:"page_bookmark_template"` // Template for all page bookmarks. This can be overriden a page
-	Watermark            struct {
-		Text       string       `json:"text"`
-		StyleList  []string     `json:"style_list"`
-		Attributes []*Attribute `json:"attributes"`
+	Watermark            Watermark       `json:"watermark"`              // Document watermark. Will be placed on every page
+	Sections             []*Section      `json:"sections,omitempty"`     // Sections, rendered after the document pages
 
-		// internal use
-		TextStyle TextBrush `json:"-"`
-	} `json:"watermark"` // Document watermark. Will be placed on every page
-
-	Title string `json:"-"`
+	Title   string  `json:"-"`
+	Margins Spacing `json:"-"` // Page margins. Unset sides keep the defaults
 }
 
 func (d *Document) HasStyle(name string) bool {
@@ -615,6 +951,25 @@ func (d *Document) HasStyle(name string) bool {
 	})
 }
 
+// Returns the document pages followed by the pages of every section
+func (d *Document) GetPages() []*Page {
+	pages := slices.Clone(d.Pages)
+	for _, section := range d.Sections {
+		pages = append(pages, section.Pages...)
+	}
+	return pages
+}
+
+// Returns the document image with the given name, or loads the image from the source
+func (d *Document) ResolveImage(src string) (*ImageData, error) {
+	for _, img := range d.Images {
+		if img.Name == src && img.Data != nil {
+			return img.Data, nil
+		}
+	}
+	return LoadImage(src)
+}
+
 func (d *Document) GetStyleByName(name string) (*Style, bool) {
 	index := slices.IndexFunc[[]*Style, *Style](d.Styles, func(s *Style) bool {
 		return s.Name == name
@@ -625,35 +980,125 @@ func (d *Document) GetStyleByName(name string) (*Style, bool) {
 	return nil, false
 }
 
-func (cell *Cell) Render(c Canvas, icell int, doc *Document, page *Page, isPageCell bool) {
-	cellx, celly := c.GetXY()
-	var cellw, cellh float64
-	dc := c.GetDrawingRect()
-
-	// allow absolute positioning on pages only
-	if isPageCell && cell.Absolute {
-		cellx = cell.Left
-		celly = cell.Top
+// Returns the margin and padding of the cell. Percentages resolve against the parent rect, or the drawing rect when nil.
+func (cell *Cell) GetSpacing(c Canvas, doc *Document, parent *Rect) (margin, padding Margins) {
+	if parent == nil {
+		parent = c.GetDrawingRect()
 	}
+	margin = cell.Margin.Resolve(parent.Right, parent.Bottom, Margins{}, doc.DisplayUnit)
+	padding = cell.Padding.Resolve(parent.Right, parent.Bottom, Margins{}, doc.DisplayUnit)
+	return margin, padding
+}
 
-	// TODO take into account cell margin
+// Returns the width and height of the cell border box. Percentages resolve against the parent rect, or the drawing rect when nil.
+// Cells without a width or height take the size of their content plus their padding.
+func (cell *Cell) GetSize(c Canvas, doc *Document, parent *Rect) (cellw, cellh float64) {
+	if parent == nil {
+		parent = c.GetDrawingRect()
+	}
+	margin, padding := cell.GetSpacing(c, doc, parent)
+	padw, padh := padding.Left+padding.Right, padding.Top+padding.Bottom
 
 	if cell.Width == nil {
 		// fallback to string width for the width
-		cellw = c.GetTextWidth(cell.Text)
+		cellw = c.GetTextWidth(cell.Text) + padw
 	} else {
-		cellw = cell.Width.GetValue(dc.Right, 0, 0, UT_LENGTH|UT_LENGH_WIDTH, doc.DisplayUnit)
+		cellw = cell.Width.GetValue(parent.Right, 0, 0, UT_LENGTH|UT_LENGH_WIDTH, doc.DisplayUnit)
 	}
 	if cell.Height == nil {
 		// fallback to
-		cellh = c.GetTextHeight()
+		cellh = c.GetTextHeight() + padh
 	} else {
-		cellh = cell.Height.GetValue(0, dc.Bottom, 0, UT_LENGTH|UT_LENGTH_HEIGHT, doc.DisplayUnit)
+		cellh = cell.Height.GetValue(0, parent.Bottom, 0, UT_LENGTH|UT_LENGTH_HEIGHT, doc.DisplayUnit)
+	}
+	if (cell.TextStyle.Wrap || cell.IsRichText()) && cell.ImageData == nil {
+		// wrapped and rich text grow the cell to fit their lines
+		if cell.Width == nil {
+			textw, _ := cell.MeasureText(c, math.Inf(1))
+			cellw = textw + padw
+			if cell.TextStyle.Wrap {
+				cellw = min(cellw, parent.Right-margin.Left-margin.Right)
+			}
+		}
+		if cell.Height == nil {
+			_, texth := cell.MeasureText(c, cellw-padw)
+			cellh = texth + padh
+		}
+	}
+	if cell.ImageData != nil && (cell.Width == nil || cell.Height == nil) {
+		// image cells fall back to the natural size of the image, keeping its aspect ratio when one side is set
+		imgw, imgh := c.MeasureImage(cell.ImageData)
+		switch {
+		case imgw <= 0 || imgh <= 0:
+		case cell.Width == nil && cell.Height == nil:
+			cellw, cellh = imgw+padw, imgh+padh
+		case cell.Width == nil:
+			cellw = (cellh-padh)*imgw/imgh + padw
+		default:
+			cellh = (cellw-padw)*imgh/imgw + padh
+		}
+	}
+	return
+}
+
+// Reports whether the cell draws rich text, from spans or markdown, rather than its text
+func (cell *Cell) IsRichText() bool {
+	return len(cell.Spans) > 0 || cell.RichText != nil
+}
+
+// Returns the rich text of the cell: its markdown paragraphs, or its spans as a single paragraph
+func (cell *Cell) Paragraphs() []TextParagraph {
+	if cell.RichText != nil {
+		return cell.RichText
 	}
+	runs := make([]TextRun, len(cell.Spans))
+	for i, span := range cell.Spans {
+		runs[i] = TextRun{Text: span.Text, Style: &span.TextStyle}
+	}
+	return []TextParagraph{{Runs: runs}}
+}
+
+// Returns the size of the text or the rich text of the cell, cell padding of the font included.
+// Wrapped text is broken to the width w, pass +Inf for the width of its longest line.
+func (cell *Cell) MeasureText(c Canvas, w float64) (tw, th float64) {
+	switch {
+	case cell.IsRichText():
+		return c.MeasureRichText(cell.Paragraphs(), w, &cell.TextStyle)
+	case cell.TextStyle.Wrap:
+		return c.MeasureWrappedText(cell.Text, w, &cell.TextStyle)
+	}
+	return c.MeasureText(cell.Text, &cell.TextStyle)
+}
+
+// Draws the text or the rich text of the cell in a box of w by h at the cursor
+func (cell *Cell) drawText(c Canvas, w, h float64, brush *TextBrush) {
+	if cell.IsRichText() {
+		c.DrawRichText(w, h, cell.Paragraphs(), brush)
+		return
+	}
+	c.DrawText(w, h, cell.Text, brush)
+}
+
+// Reports whether the cell is a container: a group with a size or a layout, drawn as a box its children are laid out in
+func (cell *Cell) IsContainer() bool {
+	return len(cell.Cells) > 0 && (cell.Width != nil || cell.Height != nil || cell.Layout != "")
+}
+
+func (cell *Cell) Render(c Canvas, icell int, doc *Document, page *Page, isPageCell bool) {
+	cellx, celly := c.GetXY()
+
+	// allow absolute positioning on pages only
+	if isPageCell && cell.Absolute {
+		cellx = cell.Left
+		celly = cell.Top
+	}
+
+	margin, padding := cell.GetSpacing(c, doc, nil)
+	cellw, cellh := cell.GetSize(c, doc, nil)
 
 	rect := Rect{
-		Left:   cellx,
-		Top:    celly,
+		Left:   cellx + margin.Left,
+		Top:    celly + margin.Top,
 		Right:  cellw,
 		Bottom: cellh,
 	}
@@ -661,12 +1106,69 @@ func (cell *Cell) Render(c Canvas, icell int, doc *Document, page *Page, isPageC
 	if cell.Background != nil {
 		c.DrawRect(rect, cell.Background)
 	}
+	cell.DrawLink(c, rect)
+
+	if cell.ImageData != nil {
+		c.DrawImage(padding.Inset(rect), cell.ImageData, &cell.ImageStyle)
+	}
 
 	cellx, celly = c.GetXY()
-	c.DrawText(cellw, cellh, cell.Text, &cell.TextStyle)
+	if cell.Margin.IsZero() && cell.Padding.IsZero() {
+		cell.drawText(c, cellw, cellh, &cell.TextStyle)
+
+		// draw border
+		cell.DrawBorder(c, cellx, celly, cellx+cellw, celly+cellh)
+		return
+	}
+
+	// draw the text in the padded box, then move past the margin box like the display style does
+	content := padding.Inset(Rect{Left: cellx + margin.Left, Top: celly + margin.Top, Right: cellw, Bottom: cellh})
+	brush := cell.TextStyle
+	brush.DisplayStyle = DISPLAY_COLUMN
+	c.SetXY(content.Left, content.Top)
+	if content.Right > 0 && content.Bottom > 0 {
+		cell.drawText(c, content.Right, content.Bottom, &brush)
+	}
+	left, top := cellx+margin.Left, celly+margin.Top
+	cell.DrawBorder(c, left, top, left+cellw, top+cellh)
+
+	switch cell.TextStyle.DisplayStyle {
+	case DISPLAY_ROW:
+		c.SetXY(c.GetDrawingRect().Left, top+cellh+margin.Bottom)
+	case DISPLAY_STACK:
+		c.SetXY(cellx, top+cellh+margin.Bottom)
+	default:
+		c.SetXY(left+cellw+margin.Right, celly)
+	}
+}
+
+// Sets the anchor of the cell at the top of the rect, and makes the rect a link to the target of the cell
+func (cell *Cell) DrawLink(c Canvas, rect Rect) {
+	if cell.Anchor != "" {
+		c.SetAnchor(cell.Anchor, rect.Top)
+	}
+	if cell.Href != "" {
+		c.Link(rect, cell.Href)
+	}
+}
 
-	// draw border
-	cell.DrawBorder(c, cellx, celly, cellx+cellw, celly+cellh)
+// Returns a copy of the cell that can be restyled without affecting the original
+func (cell *Cell) Clone() *Cell {
+	c := *cell
+	c.Element = cell.Element.Clone()
+	if cell.Width != nil {
+		w := *cell.Width
+		c.Width = &w
+	}
+	if cell.Height != nil {
+		h := *cell.Height
+		c.Height = &h
+	}
+	if cell.Gap != nil {
+		gap := *cell.Gap
+		c.Gap = &gap
+	}
+	return &c
 }
 
 func (d Document) Type() ElementType { return DOCUMENT }
@@ -674,12 +1176,16 @@ func (d Header) Type() ElementType   { return HEADER }
 func (d Footer) Type() ElementType   { return FOOTER }
 func (d Page) Type() ElementType     { return PAGE }
 func (d Cell) Type() ElementType     { return CELL }
+func (d Section) Type() ElementType  { return SECTION }
+func (d Span) Type() ElementType     { return SPAN }
 
 func (d *Document) GetElement() *Element { return &d.Element }
 func (d *Header) GetElement() *Element   { return &d.Element }
 func (d *Footer) GetElement() *Element   { return &d.Element }
 func (d *Page) GetElement() *Element     { return &d.Element }
 func (d *Cell) GetElement() *Element     { return &d.Element }
+func (d *Section) GetElement() *Element  { return &d.Element }
+func (d *Span) GetElement() *Element     { return &d.Element }
 
 func NewDimension(value float64, unit DimensionUnit) *Dimension {
 	return &Dimension{
@@ -0,0 +1,76 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpacing(t *testing.T) {
+	var m types.Spacing
+	assert.Nil(t, m.Parse("10mm 20mm 30mm"))
+	resolved := m.Resolve(210, 297, types.Margins{}, types.DU_MILIMETER)
+	assert.Equal(t, types.Margins{Left: 20, Top: 10, Right: 20, Bottom: 30}, resolved)
+
+	m = types.Spacing{}
+	assert.Nil(t, m.Parse("1cm"))
+	resolved = m.Resolve(210, 297, types.Margins{}, types.DU_MILIMETER)
+	assert.Equal(t, types.Margins{Left: 10, Top: 10, Right: 10, Bottom: 10}, resolved)
+
+	assert.EqualError(t, m.Parse("1mm 2mm 3mm 4mm 5mm"), "invalid spacing `1mm 2mm 3mm 4mm 5mm`, expected one to four dimensions")
+}
+
+func TestSectionVariantSelect(t *testing.T) {
+	first := &types.Header{}
+	even := &types.Header{}
+	head := &types.Header{Variants: map[types.SectionVariant]*types.Header{types.SV_FIRST: first, types.SV_EVEN: even}}
+
+	assert.True(t, first == head.Select("", 1, true, false))
+	assert.True(t, even == head.Select("", 2, false, false))
+	assert.True(t, head == head.Select("", 3, false, true))
+	assert.True(t, even == head.Select(types.SV_EVEN, 3, false, false))
+	assert.True(t, head == head.Select(types.SV_LAST, 3, false, false))
+}
+
+func TestPageSizeDimensions(t *testing.T) {
+	w, h, ok, err := types.PageSize("100mm x 15cm").Dimensions(types.DU_MILIMETER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.InDelta(t, 100., w, 1e-6)
+	assert.InDelta(t, 150., h, 1e-6)
+
+	_, _, ok, _ = types.PageSize("A4").Dimensions(types.DU_MILIMETER)
+	assert.False(t, ok)
+	assert.Nil(t, types.PageSize("letter").Validate())
+	assert.NotNil(t, types.PageSize("50% x 10mm").Validate())
+	assert.NotNil(t, types.PageSize("B7").Validate())
+}
+
+func TestDimensionUnits(t *testing.T) {
+	tests := []struct {
+		in       string
+		unit     types.DimensionUnit
+		value    float64
+		resolved float64 // in millimeters, of a 200mm wide parent
+	}{
+		{"10mm", types.DU_MILIMETER, 10, 10},
+		{"1.5cm", types.DU_CENTIMETER, 1.5, 15},
+		{"2in", types.DU_INCH, 2, 50.8},
+		{"25%", types.DU_PERCENT, .25, 50},
+		{"2.5IN", types.DU_INCH, 2.5, 63.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d types.Dimension
+			assert.Nil(t, d.UnmarshalText([]byte(tt.in)))
+			assert.Equal(t, tt.unit, d.Unit)
+			assert.InDelta(t, tt.value, d.Value, 1e-6)
+			assert.InDelta(t, tt.resolved, d.GetValue(200, 100, 12, types.UT_LENGTH|types.UT_LENGH_WIDTH, types.DU_MILIMETER), 1e-6)
+		})
+	}
+
+	var d types.Dimension
+	assert.EqualError(t, d.UnmarshalText([]byte("10px")), "invalid unit 10px")
+	assert.NotNil(t, d.UnmarshalText([]byte("tenmm")))
+}
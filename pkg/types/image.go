@@ -0,0 +1,149 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Controls how an image is sized within its cell
+type ImageFit string
+
+const (
+	// Scales the image to fit the cell, keeping its aspect ratio
+	IF_CONTAIN ImageFit = "contain"
+
+	// Scales the image to cover the cell, keeping its aspect ratio. Parts outside the cell are clipped
+	IF_COVER ImageFit = "cover"
+
+	// Scales the image to the size of the cell
+	IF_STRETCH ImageFit = "stretch"
+
+	// Draws the image at its natural size. Parts outside the cell are clipped
+	IF_NONE ImageFit = "none"
+)
+
+const MAX_IMAGE_FILE_SIZE = 10 * 1024 * 1024
+
+func (f *ImageFit) Parse(in string) error {
+	return parseEnum(f, in, "image fit", IF_CONTAIN, IF_COVER, IF_STRETCH, IF_NONE)
+}
+
+// Decoded image source
+type ImageData struct {
+	Key      string // Name the image is registered under in the PDF
+	Type     string // png, jpg or gif
+	FilePath string // will contain the file path if the image was loaded from a file
+	Data     []byte
+}
+
+// Image registered with the document, which cells refer to by name
+type Image struct {
+	Name   string `json:"name"`
+	Source string `json:"data"` // `file://` path, `data:` URI or base64 data
+
+	Data *ImageData `json:"-"` // internal use
+}
+
+// Describes how images are drawn
+type ImageBrush struct {
+	Fit       ImageFit // Default contain
+	Alignment string   // (L)EFT, (C)ENTER, (R)IGHT, (T)OP, (M)IDDLE, (B)OTTOM. Default centered
+	Alpha     *float64 // Opacity between 0 and 1. Default 1
+}
+
+func (b *ImageBrush) GetAlpha() float64 {
+	if b.Alpha == nil {
+		return 1
+	}
+	return *b.Alpha
+}
+
+// Returns where an image of natural size w, h is drawn within rect, and whether it must be clipped to rect
+func (b *ImageBrush) Place(rect Rect, w, h float64) (dst Rect, clip bool) {
+	if w <= 0 || h <= 0 {
+		return rect, false
+	}
+	switch b.Fit {
+	case IF_STRETCH:
+		return rect, false
+	case IF_COVER:
+		scale := max(rect.Right/w, rect.Bottom/h)
+		w, h, clip = w*scale, h*scale, true
+	case IF_NONE:
+		clip = w > rect.Right || h > rect.Bottom
+	default:
+		scale := min(rect.Right/w, rect.Bottom/h)
+		w, h = w*scale, h*scale
+	}
+
+	dst = Rect{Left: rect.Left + (rect.Right-w)/2, Top: rect.Top + (rect.Bottom-h)/2, Right: w, Bottom: h}
+	align := strings.ToUpper(b.Alignment)
+	if strings.ContainsRune(align, 'L') {
+		dst.Left = rect.Left
+	} else if strings.ContainsRune(align, 'R') {
+		dst.Left = rect.Left + rect.Right - w
+	}
+	if strings.ContainsRune(align, 'T') {
+		dst.Top = rect.Top
+	} else if strings.ContainsRune(align, 'B') {
+		dst.Top = rect.Top + rect.Bottom - h
+	}
+	return dst, clip
+}
+
+// Loads an image from a `file://` path, a `data:` URI or base64 data. PNG, JPEG and GIF images are supported.
+func LoadImage(src string) (*ImageData, error) {
+	img := &ImageData{}
+	switch {
+	case strings.HasPrefix(src, "file://"):
+		filename := filepath.Clean(src[7:])
+		stat, err := os.Stat(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to stat image file `%s`", filename)
+		}
+		if stat.Size() > MAX_IMAGE_FILE_SIZE {
+			return nil, errors.Errorf("image file '%s' exceeds '%d' bytes", filepath.Base(filename), MAX_IMAGE_FILE_SIZE)
+		}
+		if img.Data, err = os.ReadFile(filename); err != nil {
+			return nil, errors.Wrapf(err, "read image file `%s`", filepath.Base(filename))
+		}
+		img.FilePath = filename
+	case strings.HasPrefix(src, "data:"):
+		header, data, ok := strings.Cut(src, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, errors.New("image data URIs must be base64 encoded")
+		}
+		var err error
+		if img.Data, err = base64.StdEncoding.DecodeString(data); err != nil {
+			return nil, errors.Wrap(err, "decode image data")
+		}
+	default:
+		var err error
+		if img.Data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(src)); err != nil {
+			return nil, errors.New("expected a `file://` path, base64 data or the name of a document image")
+		}
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported image, expected PNG, JPEG or GIF")
+	}
+	img.Type = format
+	if format == "jpeg" {
+		img.Type = "jpg"
+	}
+	sum := sha1.Sum(img.Data)
+	img.Key = hex.EncodeToString(sum[:])
+	return img, nil
+}
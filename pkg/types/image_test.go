@@ -0,0 +1,35 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePlace(t *testing.T) {
+	rect := types.Rect{Left: 10, Top: 10, Right: 40, Bottom: 20}
+
+	brush := types.ImageBrush{}
+	dst, clip := brush.Place(rect, 20, 20)
+	assert.Equal(t, types.Rect{Left: 20, Top: 10, Right: 20, Bottom: 20}, dst)
+	assert.False(t, clip)
+
+	brush = types.ImageBrush{Fit: types.IF_COVER, Alignment: "T"}
+	dst, clip = brush.Place(rect, 20, 20)
+	assert.Equal(t, types.Rect{Left: 10, Top: 10, Right: 40, Bottom: 40}, dst)
+	assert.True(t, clip)
+
+	brush = types.ImageBrush{Fit: types.IF_NONE, Alignment: "RB"}
+	dst, clip = brush.Place(rect, 10, 5)
+	assert.Equal(t, types.Rect{Left: 40, Top: 25, Right: 10, Bottom: 5}, dst)
+	assert.False(t, clip)
+}
+
+func TestLoadImage(t *testing.T) {
+	_, err := types.LoadImage("bm90IGFuIGltYWdl")
+	assert.EqualError(t, err, "unsupported image, expected PNG, JPEG or GIF: image: unknown format")
+
+	_, err = types.LoadImage("data:image/png,abc")
+	assert.EqualError(t, err, "image data URIs must be base64 encoded")
+}
@@ -0,0 +1,260 @@
+package types
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Controls how the children of a container are laid out
+type LayoutMode string
+
+type FlexDirection string
+type FlexJustify string
+type FlexAlign string
+
+const (
+	// Children follow their display style, as cells do on a page
+	LM_FLOW LayoutMode = "flow"
+
+	// Children are distributed along a main axis, see FlexLayout
+	LM_FLEX LayoutMode = "flex"
+
+	// Children are placed on the tracks of a grid, see GridLayout
+	LM_GRID LayoutMode = "grid"
+)
+
+const (
+	FD_ROW            FlexDirection = "row"
+	FD_ROW_REVERSE    FlexDirection = "row-reverse"
+	FD_COLUMN         FlexDirection = "column"
+	FD_COLUMN_REVERSE FlexDirection = "column-reverse"
+)
+
+const (
+	FJ_START         FlexJustify = "flex-start"
+	FJ_END           FlexJustify = "flex-end"
+	FJ_CENTER        FlexJustify = "center"
+	FJ_SPACE_BETWEEN FlexJustify = "space-between"
+	FJ_SPACE_AROUND  FlexJustify = "space-around"
+	FJ_SPACE_EVENLY  FlexJustify = "space-evenly"
+)
+
+const (
+	FA_STRETCH FlexAlign = "stretch"
+	FA_START   FlexAlign = "flex-start"
+	FA_END     FlexAlign = "flex-end"
+	FA_CENTER  FlexAlign = "center"
+)
+
+func parseEnum[T ~string](dst *T, in string, kind string, values ...T) error {
+	value := T(strings.ToLower(strings.TrimSpace(in)))
+	if !slices.Contains(values, value) {
+		return errors.Errorf("invalid %s `%s`", kind, in)
+	}
+	*dst = value
+	return nil
+}
+
+func (lm *LayoutMode) Parse(in string) error {
+	return parseEnum(lm, in, "layout", LM_FLOW, LM_FLEX, LM_GRID)
+}
+
+func (fd *FlexDirection) Parse(in string) error {
+	return parseEnum(fd, in, "flex direction", FD_ROW, FD_ROW_REVERSE, FD_COLUMN, FD_COLUMN_REVERSE)
+}
+
+func (fj *FlexJustify) Parse(in string) error {
+	return parseEnum(fj, in, "justify content", FJ_START, FJ_END, FJ_CENTER, FJ_SPACE_BETWEEN, FJ_SPACE_AROUND, FJ_SPACE_EVENLY)
+}
+
+func (fa *FlexAlign) Parse(in string) error {
+	return parseEnum(fa, in, "alignment", FA_STRETCH, FA_START, FA_END, FA_CENTER)
+}
+
+// Flex layout of a container. Empty values mean row, no wrapping, flex-start and stretch.
+type FlexLayout struct {
+	Direction  FlexDirection
+	Wrap       bool
+	Justify    FlexJustify
+	AlignItems FlexAlign
+}
+
+// Reports whether the main axis is horizontal
+func (f *FlexLayout) IsRow() bool {
+	return f.Direction == "" || f.Direction == FD_ROW || f.Direction == FD_ROW_REVERSE
+}
+
+// Reports whether children are placed from the end of the main axis
+func (f *FlexLayout) IsReverse() bool {
+	return f.Direction == FD_ROW_REVERSE || f.Direction == FD_COLUMN_REVERSE
+}
+
+// Flex factors of a container child
+type FlexItem struct {
+	Grow   float64  // Share of the free space the child grows by. Default 0
+	Shrink *float64 // Share of the overflow the child shrinks by, weighted by its size. Default 1
+}
+
+func (fi *FlexItem) GetShrink() float64 {
+	if fi.Shrink == nil {
+		return 1
+	}
+	return *fi.Shrink
+}
+
+// Track of a grid: fixed (`30mm`), relative to the container (`25%`), a fraction of the free space (`1fr`) or `auto`
+type GridTrack struct {
+	Size     *Dimension // Fixed or relative size. nil for fractions and auto tracks
+	Fraction float64    // Share of the free space for fraction tracks
+}
+
+func (t GridTrack) IsAuto() bool {
+	return t.Size == nil && t.Fraction == 0
+}
+
+// Named area of a grid. Start row and column are zero-based.
+type GridArea struct {
+	Row, Column         int
+	RowSpan, ColumnSpan int
+}
+
+// Grid layout of a container
+type GridLayout struct {
+	Columns []GridTrack
+	Rows    []GridTrack // Rows are added as needed, sized by their content
+	Areas   map[string]*GridArea
+}
+
+// Placement of a child in a grid. Lines are numbered from 1 as in CSS, 0 leaves the child to automatic placement.
+type GridPlacement struct {
+	Area                    string
+	RowStart, RowSpan       int
+	ColumnStart, ColumnSpan int
+}
+
+// Parses space separated tracks, e.g. `30mm 1fr 2fr auto`
+func ParseGridTracks(in string) ([]GridTrack, error) {
+	tracks := []GridTrack{}
+	for _, field := range strings.Fields(in) {
+		field = strings.ToLower(field)
+		switch {
+		case field == "auto":
+			tracks = append(tracks, GridTrack{})
+		case strings.HasSuffix(field, "fr"):
+			fr, err := strconv.ParseFloat(strings.TrimSuffix(field, "fr"), 64)
+			if err != nil || fr <= 0 {
+				return nil, errors.Errorf("invalid track `%s`", field)
+			}
+			tracks = append(tracks, GridTrack{Fraction: fr})
+		default:
+			var size Dimension
+			if err := size.UnmarshalText([]byte(field)); err != nil {
+				return nil, errors.Wrapf(err, "invalid track `%s`", field)
+			}
+			tracks = append(tracks, GridTrack{Size: &size})
+		}
+	}
+	if len(tracks) == 0 {
+		return nil, errors.New("no tracks defined")
+	}
+	return tracks, nil
+}
+
+// Parses grid template areas given as one quoted string per row, e.g. `"logo title" "logo date"`.
+// `.` marks an unnamed cell. Each area must form a rectangle.
+func ParseGridAreas(in string) (map[string]*GridArea, error) {
+	rows := [][]string{}
+	for in = strings.TrimSpace(in); in != ""; in = strings.TrimSpace(in) {
+		quote := in[0]
+		if quote != '"' && quote != '\'' {
+			return nil, errors.Errorf("expected a quoted row at `%s`", in)
+		}
+		end := strings.IndexByte(in[1:], quote)
+		if end < 0 {
+			return nil, errors.Errorf("unterminated row `%s`", in)
+		}
+		row := strings.Fields(in[1 : end+1])
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, errors.Errorf("row %d has %d columns, expected %d", len(rows)+1, len(row), len(rows[0]))
+		}
+		rows = append(rows, row)
+		in = in[end+2:]
+	}
+
+	areas := map[string]*GridArea{}
+	for r, row := range rows {
+		for c, name := range row {
+			if name == "." {
+				continue
+			}
+			area, ok := areas[name]
+			if !ok {
+				areas[name] = &GridArea{Row: r, Column: c, RowSpan: 1, ColumnSpan: 1}
+				continue
+			}
+			// rows are scanned in order, columns may start before the first cell seen
+			if c < area.Column {
+				area.ColumnSpan += area.Column - c
+				area.Column = c
+			}
+			area.RowSpan = max(area.RowSpan, r-area.Row+1)
+			area.ColumnSpan = max(area.ColumnSpan, c-area.Column+1)
+		}
+	}
+	for name, area := range areas {
+		for r := area.Row; r < area.Row+area.RowSpan; r++ {
+			for c := area.Column; c < area.Column+area.ColumnSpan; c++ {
+				if rows[r][c] != name {
+					return nil, errors.Errorf("area `%s` is not a rectangle", name)
+				}
+			}
+		}
+	}
+	return areas, nil
+}
+
+// Parses a grid line placement: `2`, `1 / 3`, `span 2` or `1 / span 2`. Returns the start line (0 when unset)
+// and the number of tracks spanned.
+func ParseGridLine(in string) (start, span int, err error) {
+	parsePart := func(part string) (n int, isSpan bool, err error) {
+		part = strings.TrimSpace(part)
+		if rest, ok := strings.CutPrefix(part, "span"); ok {
+			part, isSpan = strings.TrimSpace(rest), true
+		}
+		n, err = strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return 0, false, errors.Errorf("invalid grid line `%s`", in)
+		}
+		return n, isSpan, nil
+	}
+
+	first, rest, hasEnd := strings.Cut(in, "/")
+	n, isSpan, err := parsePart(first)
+	if err != nil {
+		return 0, 0, err
+	}
+	if isSpan {
+		if hasEnd {
+			return 0, 0, errors.Errorf("invalid grid line `%s`", in)
+		}
+		return 0, n, nil
+	}
+	start, span = n, 1
+	if hasEnd {
+		end, isSpan, err := parsePart(rest)
+		if err != nil {
+			return 0, 0, err
+		}
+		if isSpan {
+			span = end
+		} else if end <= start {
+			return 0, 0, errors.Errorf("grid line `%s` ends before it starts", in)
+		} else {
+			span = end - start
+		}
+	}
+	return start, span, nil
+}
@@ -0,0 +1,46 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/gintec-rdl/pdf-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGridTracks(t *testing.T) {
+	tracks, err := types.ParseGridTracks("30mm 1fr auto 2fr")
+	assert.Nil(t, err)
+	assert.Len(t, tracks, 4)
+	assert.NotNil(t, tracks[0].Size)
+	assert.Equal(t, 1., tracks[1].Fraction)
+	assert.True(t, tracks[2].IsAuto())
+	assert.Equal(t, 2., tracks[3].Fraction)
+
+	_, err = types.ParseGridTracks("0fr")
+	assert.EqualError(t, err, "invalid track `0fr`")
+}
+
+func TestParseGridAreas(t *testing.T) {
+	areas, err := types.ParseGridAreas(`"logo title title" "logo . date"`)
+	assert.Nil(t, err)
+	assert.Equal(t, &types.GridArea{Row: 0, Column: 0, RowSpan: 2, ColumnSpan: 1}, areas["logo"])
+	assert.Equal(t, &types.GridArea{Row: 0, Column: 1, RowSpan: 1, ColumnSpan: 2}, areas["title"])
+	assert.Equal(t, &types.GridArea{Row: 1, Column: 2, RowSpan: 1, ColumnSpan: 1}, areas["date"])
+
+	_, err = types.ParseGridAreas(`"a b" "b b"`)
+	assert.EqualError(t, err, "area `b` is not a rectangle")
+
+	_, err = types.ParseGridAreas(`"a b" "a"`)
+	assert.EqualError(t, err, "row 2 has 1 columns, expected 2")
+}
+
+func TestParseGridLine(t *testing.T) {
+	for in, want := range map[string][2]int{"2": {2, 1}, "1 / 3": {1, 2}, "span 2": {0, 2}, "2 / span 3": {2, 3}} {
+		start, span, err := types.ParseGridLine(in)
+		assert.Nil(t, err, in)
+		assert.Equal(t, want, [2]int{start, span}, in)
+	}
+
+	_, _, err := types.ParseGridLine("3 / 1")
+	assert.EqualError(t, err, "grid line `3 / 1` ends before it starts")
+}
@@ -139,6 +139,12 @@ func (b *Brush) Copy(other *Brush) {
 	b.StrokeWidth = other.StrokeWidth
 }
 
+func (b *Brush) clone() *Brush {
+	c := *b
+	c.drawStyleStr = nil
+	return &c
+}
+
 type TextBrush struct {
 	Brush        `json:"-"`
 	FontName     string      `json:"-"`
@@ -146,6 +152,9 @@ type TextBrush struct {
 	FontSize     Dimension   `json:"-"`
 	FontStyle    FontStyle   `json:"-"`
 	DisplayStyle CellDisplay `json:"-"`
+	Wrap         bool        `json:"-"` // Break the text at word boundaries to the width of the cell, and at newlines
+	Hyphenate    bool        `json:"-"` // Hyphenate words that do not fit on a wrapped line
+	Language     string      `json:"-"` // Language of the text, selecting the hyphenation patterns
 }
 
 func (b *TextBrush) Copy(other *TextBrush) {
@@ -157,21 +166,76 @@ func (b *TextBrush) Copy(other *TextBrush) {
 	b.Alignment = other.Alignment
 	b.FontStyle = other.FontStyle
 	b.DisplayStyle = other.DisplayStyle
+	b.Wrap = other.Wrap
+	b.Hyphenate = other.Hyphenate
+	if other.Language != "" {
+		b.Language = other.Language
+	}
+}
+
+// Text drawn with its own style. Runs without a style take the style of the text they are part of.
+type TextRun struct {
+	Text  string
+	Style *TextBrush
+	Link  string // URL or `#anchor` the run links to, if set
+}
+
+// Paragraph of runs. Its lines are indented by Indent, and its first line starts with the marker drawn in the indent,
+// as for list items. The space between two paragraphs is the larger of their SpaceAfter and SpaceBefore.
+type TextParagraph struct {
+	Runs        []TextRun
+	Marker      []TextRun
+	Indent      float64
+	SpaceBefore float64
+	SpaceAfter  float64
 }
 
 type Canvas interface {
 	DrawText(w, h float64, text string, brush *TextBrush)
 	DrawRect(rect Rect, brush *Brush)
 	DrawCircle(x, y, r float64, brush *Brush)
-	DrawImage(x, y, w, h, float64, brush *Brush)
+
+	// Draws the image in the rect, fitted and aligned according to the brush
+	DrawImage(rect Rect, img *ImageData, brush *ImageBrush)
 	DrawLine(x1, y1, x2, y2 float64, brush *Brush)
 	GetDrawingRect() *Rect
 	GetPageRect() *Rect
+
+	// Returns and sets the margins of the drawing rect on the current page
+	GetMargins() Margins
+	SetMargins(m Margins)
 	GetWidth() float64
 	GetHeight() float64
 	GetTextWidth(text string) float64
 	GetTextHeight() float64
 	GetFontSize() float64
+
+	// Returns the size of the box needed to draw a line of text with the brush, cell padding included.
+	// Wrapped text is broken at its newlines only.
+	MeasureText(text string, brush *TextBrush) (w, h float64)
+
+	// Returns the size of the box needed to draw the text wrapped to the width w, cell padding included
+	MeasureWrappedText(text string, w float64, brush *TextBrush) (tw, th float64)
+
+	// Draws the paragraphs in the box as a block of lines aligned by the brush, then moves the cursor like DrawText.
+	// Lines break at newlines, and at word boundaries when the brush wraps.
+	DrawRichText(w, h float64, paragraphs []TextParagraph, brush *TextBrush)
+
+	// Returns the size of the box needed to draw the paragraphs, cell padding included. Lines are broken to the width w
+	// when the brush wraps.
+	MeasureRichText(paragraphs []TextParagraph, w float64, brush *TextBrush) (tw, th float64)
+
+	// Returns the natural size of the image
+	MeasureImage(img *ImageData) (w, h float64)
+
+	// Makes the rect a link to the target, a URL or `#` followed by the name of an anchor
+	Link(rect Rect, target string)
+
+	// Sets the anchor of the given name at the position y on the current page, the destination of links to `#name`
+	SetAnchor(name string, y float64)
+
+	// Makes the rect a link to the position y on a physical page, starting at 1, which can be added later
+	LinkPage(rect Rect, page int, y float64)
 	GetX() float64
 	SetX(float64)
 	GetY() float64
@@ -180,18 +244,57 @@ type Canvas interface {
 	SetXY(float64, float64)
 	Save()
 	Restore()
+
+	// Transforms what is drawn until EndTransform. Rotations are in degrees, counter-clockwise around x, y
+	BeginTransform()
+	Rotate(angle, x, y float64)
+	Translate(dx, dy float64)
+	EndTransform()
 }
 
 type PdfPage interface {
 	GetCanvas() Canvas
 }
 
+// Space on each side of a page or box, in document units
+type Margins struct {
+	Left, Top, Right, Bottom float64
+}
+
+// Returns the rect shrunk by the margins
+func (m Margins) Inset(r Rect) Rect {
+	return Rect{Left: r.Left + m.Left, Top: r.Top + m.Top, Right: r.Right - m.Left - m.Right, Bottom: r.Bottom - m.Top - m.Bottom}
+}
+
+// Format and margins of a physical page. Empty fields take the document defaults.
+type PageSetup struct {
+	Orientation PageOrientation
+	Size        PageSize
+	Margins     *Margins
+
+	NoAutoPageBreak bool // Leave page breaks to the caller, as the template renderer does
+}
+
 type PdfDocument interface {
-	AddNewPage(footerNHeaderFn func(p PdfPage, pageIndex int, inFooter bool)) PdfPage
-	SetBookmark(title string)
+	// Adds a page with the given setup, the document defaults when nil
+	AddNewPage(setup *PageSetup, footerNHeaderFn func(p PdfPage, pageIndex int, inFooter bool)) PdfPage
+	// Adds an outline entry at the position y on the current page, -1 for the cursor. Levels start at 0, each level
+	// nested below the previous entry of the level above
+	SetBookmark(title string, level int, y float64)
+	// Returns the page, starting at 1, and the position y of an anchor set so far
+	GetAnchor(name string) (page int, y float64, ok bool)
 	SetTitle(title string)
 	GetPage(page int) (PdfPage, bool)
 	GetPageCount() int
+
+	// Returns the width and height of pages with the given setup, the document defaults when nil
+	GetPageSize(setup *PageSetup) (w, h float64)
+
+	// Returns the default page margins
+	GetMargins() Margins
+
+	// Returns an empty document with the same page setup, used to lay out content before rendering
+	NewLayoutDocument() (PdfDocument, error)
 	InitializeFonts(fonts *[]*Font) error
 	AddFont(fontname string, data []byte)
 	SaveAndCloseF(dst string) error
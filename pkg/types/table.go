@@ -0,0 +1,52 @@
+package types
+
+import (
+	"strings"
+)
+
+// Column definition of a table
+type TableColumn struct {
+	Width string `json:"width"` // Fixed (`30mm`), relative to the table width (`25%`) or `auto` to size by content
+
+	Size *Dimension `json:"-"` // Parsed width. nil for auto columns
+}
+
+// Reports whether the column is sized by its content
+func (col *TableColumn) IsAuto() bool {
+	return col.Size == nil
+}
+
+// Parses the column width
+func (col *TableColumn) Parse() error {
+	if col.Width == "" || strings.EqualFold(col.Width, "auto") {
+		col.Size = nil
+		return nil
+	}
+	col.Size = new(Dimension)
+	return col.Size.UnmarshalText([]byte(col.Width))
+}
+
+type TableRow struct {
+	Element
+	Cells []*Cell `json:"cells"`
+
+	Height *Dimension `json:"-"` // Minimum row height. Rows grow to fit their tallest cell
+	Repeat string     `json:"-"` // Repeat expression. Renders the row once per array element
+}
+
+// Table element. Header rows are repeated at the top of every page the table breaks onto.
+type Table struct {
+	Element
+	Columns []*TableColumn `json:"columns"`
+	Header  []*TableRow    `json:"header"`
+	Rows    []*TableRow    `json:"rows"`
+
+	Width        *Dimension `json:"-"` // Table width. Defaults to the width of the drawing area
+	RepeatHeader bool       `json:"-"` // Repeat the header rows on every page. Default true
+}
+
+func (d Table) Type() ElementType    { return TABLE }
+func (d TableRow) Type() ElementType { return TABLE_ROW }
+
+func (d *Table) GetElement() *Element    { return &d.Element }
+func (d *TableRow) GetElement() *Element { return &d.Element }
@@ -13,6 +13,11 @@ type PdfTemplate interface {
 	GetOrientation() PageOrientation
 	RenderW(pdfDoc PdfDocument, w io.Writer) error
 	RenderF(pdfDoc PdfDocument, filename string) error
+
+	// Render against data. Data can be a map, a struct or raw JSON ([]byte, json.RawMessage).
+	// Expressions such as `${customer.name}` in cell text and bookmark titles are resolved against it.
+	RenderDataW(pdfDoc PdfDocument, data any, w io.Writer) error
+	RenderDataF(pdfDoc PdfDocument, data any, filename string) error
 }
 
 type PdfTemplateAttributes map[string]string
@@ -23,28 +28,126 @@ type PdfTemplateAttributeContainer interface {
 	Attributes(attrs PdfTemplateAttributes) PdfTemplateAttributeContainer
 }
 
+// An element that holds child cells
+type PdfTemplateCellContainer interface {
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
+}
+
+// Child cell of a group cell
+type PdfTemplateChildCell interface {
+	Parent() PdfTemplateCellContainer
+	Text(text string) PdfTemplateChildCell
+
+	// Appends a run of text with its own font attributes and styles. Spans are rendered in place of the text
+	Span(text string, attrs PdfTemplateAttributes, styles ...string) PdfTemplateChildCell
+	Attribute(name, value string) PdfTemplateChildCell
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateChildCell
+	StyleList(name string, more ...string) PdfTemplateChildCell
+	Repeat(expression string) PdfTemplateChildCell
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
+}
+
 type PdfTemplateHeaderCell interface {
 	Parent() PdfTemplateHeader
 	Text(text string) PdfTemplateHeaderCell
+
+	// Appends a run of text with its own font attributes and styles. Spans are rendered in place of the text
+	Span(text string, attrs PdfTemplateAttributes, styles ...string) PdfTemplateHeaderCell
 	Attribute(name, value string) PdfTemplateHeaderCell
 	Attributes(attrs PdfTemplateAttributes) PdfTemplateHeaderCell
 	StyleList(name string, more ...string) PdfTemplateHeaderCell
+
+	// Repeats the cell once per element of the array returned by the expression
+	Repeat(expression string) PdfTemplateHeaderCell
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
 }
 
 type PdfTemplateFooterCell interface {
 	Parent() PdfTemplateFooter
 	Text(text string) PdfTemplateFooterCell
+
+	// Appends a run of text with its own font attributes and styles. Spans are rendered in place of the text
+	Span(text string, attrs PdfTemplateAttributes, styles ...string) PdfTemplateFooterCell
 	Attribute(name, value string) PdfTemplateFooterCell
 	Attributes(attrs PdfTemplateAttributes) PdfTemplateFooterCell
 	StyleList(name string, more ...string) PdfTemplateFooterCell
+
+	// Repeats the cell once per element of the array returned by the expression
+	Repeat(expression string) PdfTemplateFooterCell
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
 }
 
 type PdfTemplatePageCell interface {
 	Parent() PdfTemplatePage
 	Text(text string) PdfTemplatePageCell
+
+	// Appends a run of text with its own font attributes and styles. Spans are rendered in place of the text
+	Span(text string, attrs PdfTemplateAttributes, styles ...string) PdfTemplatePageCell
 	Attribute(name, value string) PdfTemplatePageCell
 	Attributes(attrs PdfTemplateAttributes) PdfTemplatePageCell
 	StyleList(name string, more ...string) PdfTemplatePageCell
+
+	// Repeats the cell once per element of the array returned by the expression
+	Repeat(expression string) PdfTemplatePageCell
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
+}
+
+type PdfTemplateTableCell interface {
+	Parent() PdfTemplateTableRow
+	Text(text string) PdfTemplateTableCell
+
+	// Appends a run of text with its own font attributes and styles. Spans are rendered in place of the text
+	Span(text string, attrs PdfTemplateAttributes, styles ...string) PdfTemplateTableCell
+	Attribute(name, value string) PdfTemplateTableCell
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateTableCell
+	StyleList(name string, more ...string) PdfTemplateTableCell
+
+	// Repeats the cell once per element of the array returned by the expression, one column per element
+	Repeat(expression string) PdfTemplateTableCell
+	ColSpan(columns int) PdfTemplateTableCell
+	RowSpan(rows int) PdfTemplateTableCell
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateChildCell
+}
+
+type PdfTemplateTableRow interface {
+	Parent() PdfTemplateTable
+	Builder() PdfTemplateBuilder
+	AddCell() PdfTemplateTableCell
+	Attribute(name, value string) PdfTemplateTableRow
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateTableRow
+	StyleList(name string, more ...string) PdfTemplateTableRow
+
+	// Repeats the row once per element of the array returned by the expression
+	Repeat(expression string) PdfTemplateTableRow
+}
+
+type PdfTemplateTable interface {
+	Parent() PdfTemplatePage
+	Builder() PdfTemplateBuilder
+
+	// Adds columns with fixed (`30mm`), relative (`25%`) or `auto` widths
+	Columns(width string, more ...string) PdfTemplateTable
+
+	// Adds a header row. Header rows are repeated on every page the table breaks onto
+	AddHeaderRow() PdfTemplateTableRow
+	AddRow() PdfTemplateTableRow
+	Attribute(name, value string) PdfTemplateTable
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateTable
+	StyleList(name string, more ...string) PdfTemplateTable
+}
+
+type PdfTemplateToc interface {
+	Parent() PdfTemplatePage
+	Builder() PdfTemplateBuilder
+	Attribute(name, value string) PdfTemplateToc
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateToc
+	StyleList(name string, more ...string) PdfTemplateToc
 }
 
 type PdfTemplateHeader interface {
@@ -66,33 +169,98 @@ type PdfTemplateFooter interface {
 
 type PdfTemplatePage interface {
 	AddCell() PdfTemplatePageCell
+
+	// Adds a group cell whose child cells are rendered once per element of the array returned by the expression
+	AddRepeat(expression string) PdfTemplatePageCell
+	AddTable() PdfTemplateTable
+
+	// Adds a table of contents listing the bookmarked pages and cells of the document
+	AddToc() PdfTemplateToc
+
+	// Adds a cell drawing the image from a `file://` path, base64 data or the name of a document image
+	AddImage(source string) PdfTemplatePageCell
 	Builder() PdfTemplateBuilder
 	Attribute(name, value string) PdfTemplatePage
 	BookmarkTitle(bookmark string) PdfTemplatePage
+
+	// Sets the size and orientation of the page, overriding those of its section or document. Empty values are ignored
+	PageSetup(orientation PageOrientation, pageSize PageSize) PdfTemplatePage
 	StyleList(name string, more ...string) PdfTemplatePage
 	Attributes(attrs PdfTemplateAttributes) PdfTemplatePage
 }
 
+type PdfTemplateSection interface {
+	AddPage() PdfTemplatePage
+	Builder() PdfTemplateBuilder
+	Attribute(name, value string) PdfTemplateSection
+	Attributes(attrs PdfTemplateAttributes) PdfTemplateSection
+	StyleList(name string, more ...string) PdfTemplateSection
+
+	// Sets the size and orientation of the section pages. Empty values are ignored
+	PageSetup(orientation PageOrientation, pageSize PageSize) PdfTemplateSection
+
+	// Restarts the page numbering at the given number on the first page of the section
+	PageNumber(number int) PdfTemplateSection
+	PageBookmarkTemplate(template string) PdfTemplateSection
+
+	// Sets the title of the section bookmark, the parent of the bookmarks of its pages
+	BookmarkTitle(bookmark string) PdfTemplateSection
+
+	// Returns the header of the section, or its variant when one is given. Sections use the document header until it is called.
+	Header(variant ...SectionVariant) PdfTemplateHeader
+
+	// Returns the footer of the section, or its variant when one is given. Sections use the document footer until it is called.
+	Footer(variant ...SectionVariant) PdfTemplateFooter
+}
+
 type PdfTemplateWatermark interface {
 	Builder() PdfTemplateBuilder
 	Attribute(name, value string) PdfTemplateWatermark
 	Attributes(attrs PdfTemplateAttributes) PdfTemplateWatermark
 
+	// Sets the image drawn by the watermark, from a `file://` path, base64 data or the name of a document image
+	Image(source string) PdfTemplateWatermark
+
+	// Sets the size of the image (`40mm`, `50%`). Pass an empty string to keep the aspect ratio on that side
+	Size(width, height string) PdfTemplateWatermark
+
+	// Places the watermark (`CM`, `RT`, ...) and rotates it by angle degrees, counter-clockwise
+	Placement(position string, angle float64) PdfTemplateWatermark
+
+	// Draws the watermark only on the pages where the condition holds, e.g. `page == 1` or `invoice.paid`
+	If(condition string) PdfTemplateWatermark
+
 	StyleList(name string, more ...string) PdfTemplateWatermark
 }
 
 type PdfTemplateBuilder interface {
 	AddPage() PdfTemplatePage
-	Header() PdfTemplateHeader
-	Footer() PdfTemplateFooter
+
+	// Adds a section, whose pages follow the document pages and the previous sections
+	AddSection() PdfTemplateSection
+
+	// Returns the default header, or the header of the first, odd, even or last pages when a variant is given
+	Header(variant ...SectionVariant) PdfTemplateHeader
+
+	// Returns the default footer, or the footer of the first, odd, even or last pages when a variant is given
+	Footer(variant ...SectionVariant) PdfTemplateFooter
 	Build() (PdfTemplate, error)
 	Title(title string) PdfTemplateBuilder
 	Style(name string, attrs PdfTemplateAttributes) PdfTemplateBuilder
 	StyleList(name string, more ...string) PdfTemplateBuilder
 	ShowBookmarks(show bool) PdfTemplateBuilder
+
+	// Sets the page margins with one to four dimensions, in the order top, right, bottom, left
+	Margin(margin string) PdfTemplateBuilder
 	PageBookmarkTemplate(template string) PdfTemplateBuilder
 	AddFontFromFile(fontFamily string, style FontStyle, filepath string) PdfTemplateBuilder
+
+	// Registers an image cells can refer to by name. The source is a `file://` path or base64 data
+	AddImage(name, source string) PdfTemplateBuilder
 	Watermark(text string) PdfTemplateWatermark
+
+	// Adds an image watermark, such as a faint logo or a rubber stamp
+	WatermarkImage(source string) PdfTemplateWatermark
 	Attribute(name, value string) PdfTemplateBuilder
 	Attributes(attrs PdfTemplateAttributes) PdfTemplateBuilder
 }
@@ -0,0 +1,14 @@
+package types
+
+// Table of contents element, hosted by a cell. Lists the bookmarked pages and cells of the document with their
+// page numbers, joined by leaders, each entry linking to its destination.
+type Toc struct {
+	Element
+
+	Leader string     `json:"-"` // Text repeated between the titles and the page numbers. Default `.`
+	Indent *Dimension `json:"-"` // Indent of the entries per level. Default 5mm
+}
+
+func (d Toc) Type() ElementType { return TOC }
+
+func (d *Toc) GetElement() *Element { return &d.Element }
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Controls whether the watermark is drawn behind or above the page content
+type WatermarkLayer string
+
+const (
+	WL_BEHIND WatermarkLayer = "behind"
+	WL_ABOVE  WatermarkLayer = "above"
+)
+
+func (l *WatermarkLayer) Parse(in string) error {
+	return parseEnum(l, in, "watermark layer", WL_BEHIND, WL_ABOVE)
+}
+
+// Document watermark: text, an image or both. Will be placed on every page, unless the page opts out or the condition does not hold
+type Watermark struct {
+	Text       string       `json:"text"`
+	StyleList  []string     `json:"style_list"`
+	Attributes []*Attribute `json:"attributes"`
+
+	// internal use
+	TextStyle  TextBrush      `json:"-"`
+	Angle      float64        `json:"-"` // Rotation in degrees, counter-clockwise. Default 45
+	Position   string         `json:"-"` // (L)EFT, (C)ENTER, (R)IGHT, (T)OP, (M)IDDLE, (B)OTTOM within the drawing area. Default centered
+	Layer      WatermarkLayer `json:"-"` // Default behind
+	Fit        *bool          `json:"-"` // Scale the text to the page diagonal. Default true when no font size is set
+	Condition  string         `json:"-"` // Pages the watermark is drawn on. Evaluated against the data, `page` and `total`
+	Image      string         `json:"-"` // Image source: `file://` path, base64 data or the name of a document image
+	ImageData  *ImageData     `json:"-"` // Image loaded from the source
+	ImageStyle ImageBrush     `json:"-"` // Opacity of the image
+	Width      *Dimension     `json:"-"` // Width of the image. Omit to use the natural width, or keep the aspect ratio
+	Height     *Dimension     `json:"-"` // Height of the image. Omit to use the natural height, or keep the aspect ratio
+}
+
+func (w *Watermark) GetFit() bool {
+	return w.Fit != nil && *w.Fit
+}
+
+// Returns the size of the watermark image. Percentages resolve against the drawing area.
+func (wm *Watermark) GetImageSize(c Canvas, unit DimensionUnit) (w, h float64) {
+	imgw, imgh := c.MeasureImage(wm.ImageData)
+	if imgw <= 0 || imgh <= 0 {
+		return 0, 0
+	}
+	dc := c.GetDrawingRect()
+	w, h = imgw, imgh
+	if wm.Width != nil {
+		w = wm.Width.GetValue(dc.Right, dc.Bottom, 0, UT_LENGTH|UT_LENGH_WIDTH, unit)
+	}
+	if wm.Height != nil {
+		h = wm.Height.GetValue(dc.Right, dc.Bottom, 0, UT_LENGTH|UT_LENGTH_HEIGHT, unit)
+	}
+	// keep the aspect ratio when only one side is set
+	if wm.Width != nil && wm.Height == nil {
+		h = w * imgh / imgw
+	} else if wm.Height != nil && wm.Width == nil {
+		w = h * imgw / imgh
+	}
+	return w, h
+}
+
+// Returns the center of a box of size w, h rotated by the watermark angle and positioned within rect
+func (wm *Watermark) Center(rect Rect, w, h float64) (x, y float64) {
+	rad := wm.Angle * math.Pi / 180
+	sin, cos := math.Abs(math.Sin(rad)), math.Abs(math.Cos(rad))
+	bw, bh := w*cos+h*sin, w*sin+h*cos
+
+	x, y = rect.Left+rect.Right/2, rect.Top+rect.Bottom/2
+	position := strings.ToUpper(wm.Position)
+	if strings.ContainsRune(position, 'L') {
+		x = rect.Left + bw/2
+	} else if strings.ContainsRune(position, 'R') {
+		x = rect.Left + rect.Right - bw/2
+	}
+	if strings.ContainsRune(position, 'T') {
+		y = rect.Top + bh/2
+	} else if strings.ContainsRune(position, 'B') {
+		y = rect.Top + rect.Bottom - bh/2
+	}
+	return x, y
+}